@@ -0,0 +1,217 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/utils"
+)
+
+type browseChannelsUpdate struct {
+	tab      int
+	channels []slack.Channel
+}
+
+// sent once we're in a channel we just joined or made
+type joinedChannelUpdate struct {
+	tab     int
+	channel slack.Channel
+}
+
+// every public channel in the workspace, not just the ones we're in
+func getAllChannels(slackClient *slack.Client, tab int) tea.Cmd {
+	return func() tea.Msg {
+		all := []slack.Channel{}
+		cursor := ""
+		for {
+			channels, next, err := slackClient.GetConversations(&slack.GetConversationsParameters{Cursor: cursor, ExcludeArchived: true, Limit: 1000, Types: []string{"public_channel"}})
+			if err != nil {
+				log.Error("error browsing channels", "err", err)
+				return errMsg{err}
+			}
+			all = append(all, channels...)
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+
+		// busiest first, that's usually what you're looking for
+		slices.SortStableFunc(all, func(a, b slack.Channel) int {
+			return b.NumMembers - a.NumMembers
+		})
+
+		return browseChannelsUpdate{tab, all}
+	}
+}
+
+func joinChannel(slackClient *slack.Client, channel slack.Channel, tab int) tea.Cmd {
+	return func() tea.Msg {
+		joined, _, _, err := slackClient.JoinConversation(channel.ID)
+		if err != nil {
+			log.Error("error joining channel", "channel", channel.ID, "err", err)
+			return statusUpdate{tab, "couldn't join #" + channel.Name + ": " + err.Error()}
+		}
+
+		return joinedChannelUpdate{tab, *joined}
+	}
+}
+
+func createChannel(slackClient *slack.Client, name string, private bool, tab int) tea.Cmd {
+	return func() tea.Msg {
+		channel, err := slackClient.CreateConversation(slack.CreateConversationParams{ChannelName: name, IsPrivate: private})
+		if err != nil {
+			log.Error("error creating channel", "name", name, "err", err)
+			return statusUpdate{tab, "couldn't create #" + name + ": " + err.Error()}
+		}
+
+		return joinedChannelUpdate{tab, *channel}
+	}
+}
+
+func (m Model) openBrowser(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.state = "browse"
+	t.status = ""
+	t.browseCursor = 0
+	t.browseInput.Reset()
+	t.browseInput.Placeholder = "search channels"
+
+	if t.browseChannels == nil {
+		t.status = "loading channels..."
+		return tea.Batch(t.browseInput.Focus(), getAllChannels(m.slackClient, tab))
+	}
+
+	return t.browseInput.Focus()
+}
+
+// the browser's search text carries over as the new channel's name
+func (m Model) openCreateChannel(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.state = "createChannel"
+	t.status = ""
+	t.createPrivate = tab == 1
+	t.browseInput.SetValue(channelName(t.browseInput.Value()))
+	t.browseInput.Placeholder = "new-channel-name"
+
+	return t.browseInput.Focus()
+}
+
+// slack names are lowercase with dashes instead of spaces
+func channelName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "#"))), " ", "-")
+}
+
+func (t tab) browseResults() []slack.Channel {
+	query := strings.ToLower(strings.TrimPrefix(t.browseInput.Value(), "#"))
+	if query == "" {
+		return t.browseChannels
+	}
+
+	return rankMatches(t.browseChannels, query, func(c slack.Channel) []string {
+		return []string{c.Name, c.Topic.Value, c.Purpose.Value}
+	})
+}
+
+func (m Model) joinSelectedChannel(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	results := t.browseResults()
+	if t.browseCursor >= len(results) {
+		return nil
+	}
+
+	channel := results[t.browseCursor]
+	t.status = "joining #" + channel.Name + "..."
+	return joinChannel(m.slackClient, channel, tab)
+}
+
+func (m Model) createBrowsedChannel(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	name := channelName(t.browseInput.Value())
+	if name == "" {
+		t.status = "give the channel a name first"
+		return nil
+	}
+
+	t.status = "creating #" + name + "..."
+	return createChannel(m.slackClient, name, t.createPrivate, tab)
+}
+
+func (m Model) updateBrowser(tab int, msg tea.Msg) tea.Cmd {
+	t := &m.tabs[tab]
+
+	value := t.browseInput.Value()
+	var cmd tea.Cmd
+	t.browseInput, cmd = t.browseInput.Update(msg)
+	if t.browseInput.Value() != value {
+		t.browseCursor = 0
+	}
+
+	return cmd
+}
+
+// how many channels fit on screen, each one takes two lines
+func (m Model) browseRows() int {
+	return max((m.tabs[m.activeTab].messagePager.Height-2)/2, 1)
+}
+
+func browseView(m Model) string {
+	t := m.tabs[m.activeTab]
+	results := t.browseResults()
+
+	var b strings.Builder
+	if t.browseChannels != nil && len(results) == 0 {
+		b.WriteString(mutedStyle.Render("  no channels match, ctrl+n to make it"))
+	}
+
+	// keep the cursor in view by scrolling a page at a time
+	rows := m.browseRows()
+	start := t.browseCursor / rows * rows
+	for i := start; i < min(start+rows, len(results)); i++ {
+		channel := results[i]
+
+		name := "#" + channel.Name
+		details := fmt.Sprintf("  %d members", channel.NumMembers)
+		if channel.IsMember {
+			details += ", joined"
+		}
+		about := channel.Topic.Value
+		if about == "" {
+			about = channel.Purpose.Value
+		}
+		about = utils.ClampString(strings.ReplaceAll(about, "\n", " "), m.width-16)
+
+		if i == t.browseCursor {
+			b.WriteString(selectedItemStyle.Render("> "+name) + lessMutedStyle.Render(details) + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(name) + lessMutedStyle.Render(details) + "\n")
+		}
+		b.WriteString(itemStyle.Render(mutedStyle.Render(about)) + "\n")
+	}
+
+	list := lipgloss.NewStyle().Height(rows * 2).Render(b.String())
+	hint := lessMutedStyle.Render("enter to join, ctrl+n to create a channel, ctrl+b to go back")
+
+	return list + "\n" + statusView(m) + hint + "\n" + t.browseInput.View()
+}
+
+func createChannelView(m Model) string {
+	t := m.tabs[m.activeTab]
+
+	public, private := "(x) public", "( ) private"
+	if t.createPrivate {
+		public, private = "( ) public", "(x) private"
+	}
+
+	return lessMutedStyle.Render("create a channel") + "\n\n" +
+		t.browseInput.View() + "\n\n" +
+		evenLessMutedStyle.Render(public+"  "+private) + mutedStyle.Render("  (tab to switch)") + "\n\n" +
+		statusView(m) +
+		lessMutedStyle.Render("enter to create, ctrl+b to go back")
+}
@@ -2,6 +2,7 @@ package bubbleViews
 
 import (
 	"cmp"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -15,10 +16,10 @@ import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
@@ -28,6 +29,7 @@ import (
 	"github.com/slack-go/slack"
 
 	"charming-slack/libs/database"
+	"charming-slack/libs/events"
 	"charming-slack/libs/keymaps"
 	"charming-slack/libs/utils"
 
@@ -61,6 +63,9 @@ var evenLessMutedStyle = mutedStyle.
 var messageStyle = lipgloss.NewStyle().
 	Border(lipgloss.NormalBorder()).PaddingLeft(1).PaddingRight(1)
 
+var selectedMessageStyle = messageStyle.
+	Border(lipgloss.ThickBorder()).BorderForeground(lipgloss.Color("#866bef"))
+
 type tab struct {
 	title          string
 	content        func(lipgloss.Style, Model) string
@@ -68,8 +73,106 @@ type tab struct {
 	searchMessages []slack.SearchMessage
 	state          string
 	messagePager   viewport.Model
-	messageInput   textinput.Model
+	messageInput   textarea.Model
 	focused        int
+	channel        string
+	// rendered message bodies keyed by timestamp so live updates don't redraw everything
+	rendered map[string]string
+	// the line each message starts on in the pager, in the same order as messages or the search results
+	offsets           []int
+	threadTimestamp   string
+	threadMessages    []slack.Message
+	threadPager       viewport.Model
+	alsoSendToChannel bool
+	// the message selected in the pager, an index into messages
+	cursor         int
+	actionCursor   int
+	reactionInput  textinput.Model
+	reactionCursor int
+	// set while the composer is rewriting an existing message instead of sending a new one
+	editTimestamp string
+	// the message the action menu was opened on, whatever it leads to acts on this one
+	// even if new messages move the cursor in the meantime
+	actionTimestamp string
+	// the draft we last warned about ambiguous names in, sending it again posts it anyway
+	mentionWarning string
+	status         string
+	// where the next page of older history starts, empty once we've hit the beginning
+	historyCursor   string
+	historyLoading  bool
+	historyComplete bool
+	showPreview     bool
+	previewPager    viewport.Model
+	// bumped on every keystroke so only the last scheduled preview render runs
+	previewVersion int
+	// the @, # or : word being completed and what the popup suggests for it
+	completionQuery     string
+	completions         []completion
+	completionCursor    int
+	completionDismissed bool
+	// the channel browser's search box, also used for naming a new channel
+	browseInput    textinput.Model
+	browseCursor   int
+	browseChannels []slack.Channel
+	createPrivate  bool
+	// the channel info side panel, which page of members it's on and the topic or purpose being edited
+	showInfo  bool
+	info      *channelInfo
+	infoPage  int
+	infoField string
+	infoInput textinput.Model
+	// the message to select once the conversation loads, set when jumping in from elsewhere
+	jumpTo string
+	// what the saved tab lists and how many of its two sources have come back
+	savedItems  []savedItem
+	savedCursor int
+	savedLoaded int
+	// bumped on every load so a slow one finishing late can't overwrite a newer one
+	savedGeneration int
+	limitedPins     bool
+	// your own status, it can be edited from any tab
+	statusEditor statusEditor
+	// whose profile card is open and their avatar once it's been drawn
+	profileUser   string
+	profileAvatar string
+	// the user picker shares the browser's search box and cursor
+	picked []string
+	// the search tab's filters, what they compiled to and where we are in the results
+	searchForm  searchForm
+	searchQuery string
+	searchPage  int
+	searchPages int
+	searchTotal int
+	// the fetched message count shown under the form in local mode and the query it counted
+	localMatches    string
+	localMatchQuery string
+	// file results are picked from a list and opened into a detail view with a preview
+	searchFiles  []slack.File
+	searchCursor int
+	filePreview  string
+}
+
+func newTab(title string, content func(lipgloss.Style, Model) string, pager viewport.Model, input textinput.Model, composer textarea.Model) tab {
+	// the preview shares its space with a label
+	preview := pager
+	preview.Height--
+
+	return tab{
+		title:          title,
+		content:        content,
+		messages:       []slack.Message{},
+		searchMessages: []slack.SearchMessage{},
+		state:          "select",
+		messagePager:   pager,
+		threadPager:    pager,
+		previewPager:   preview,
+		messageInput:   composer,
+		reactionInput:  input,
+		browseInput:    input,
+		infoInput:      input,
+		searchForm:     newSearchForm(input),
+		rendered:       map[string]string{},
+	}
 }
 
 type Model struct {
@@ -79,16 +182,26 @@ type Model struct {
 	time               time.Time
 	publicKey          ssh.PublicKey
 	slackClient        *slack.Client
+	events             events.Source
+	ctx                context.Context
+	output             *termenv.Output
 	help               help.Model
 	term               string
 	user               string
+	userID             string
 	page               string
 	keys               keymaps.KeyMap
 	tabs               []tab
 	channels           []slack.Channel
 	privateChannels    []slack.Channel
 	dms                []slack.Channel
+	dmNames            []string
+	unreads            map[string]unreadCount
+	directory          []slack.User
+	unreadCursor       int
+	unreadsStarted     bool
 	searchInput        textinput.Model
+	switcher           switcher
 	width              int
 	height             int
 	activeTab          int
@@ -114,13 +227,16 @@ func (d itemDelegate) Height() int                             { return 1 }
 func (d itemDelegate) Spacing() int                            { return 0 }
 func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
-	i, ok := listItem.(item)
-	if !ok {
+	var str string
+	switch i := listItem.(type) {
+	case item:
+		str = fmt.Sprintf("%d. %s", index+1, i)
+	case conversationItem:
+		str = fmt.Sprintf("%d. %s", index+1, i.name) + i.badges()
+	default:
 		return
 	}
 
-	str := fmt.Sprintf("%d. %s", index+1, i)
-
 	fn := itemStyle.Render
 	if index == m.Index() {
 		fn = func(s ...string) string {
@@ -156,24 +272,17 @@ func FirstLineDefenseMiddleware() wish.Middleware {
 		if _, ok := database.DB.ApplicationData[s.User()]; ok {
 			log.Info("existing user")
 			// check the key is the one we expect
-			if s.PublicKey() != nil {
-				for user, userData := range database.DB.ApplicationData {
-					parsed, _, _, _, _ := ssh.ParseAuthorizedKey(
-						[]byte(userData.PublicKey),
-					)
-					if ssh.KeysEqual(s.PublicKey(), parsed) && user == s.User() {
-						if userData.SlackToken != "" {
-							page = "home"
-							log.Info("authorized by public key and slack integration is installed")
-						} else {
-							page = "slackOnboarding"
-							log.Info("authorized by public key")
-							log.Info("needs to install slack integration (redirecting to slack onboarding page)")
-						}
-					} else {
-						log.Info("not authorized by public key (redirecting to auth page)")
-					}
+			if userData, ok := database.AuthenticateUser(s.User(), s.PublicKey()); ok {
+				if userData.SlackToken != "" {
+					page = "home"
+					log.Info("authorized by public key and slack integration is installed")
+				} else {
+					page = "slackOnboarding"
+					log.Info("authorized by public key")
+					log.Info("needs to install slack integration (redirecting to slack onboarding page)")
 				}
+			} else {
+				log.Info("not authorized by public key (redirecting to auth page)")
 			}
 		} else {
 			log.Info("new user")
@@ -203,10 +312,10 @@ func FirstLineDefenseMiddleware() wish.Middleware {
 		ti.CharLimit = 156
 		ti.Width = 20
 
-		mi := ti
-		mi.Placeholder = "your message here"
+		mi := newComposer(pty.Window.Width - 4)
 
-		p := viewport.New(pty.Window.Width-4, pty.Window.Height-4-2)
+		// the pager gives up the lines the composer grew by
+		p := viewport.New(pty.Window.Width-4, pty.Window.Height-4-2-(composerHeight-1))
 		p.Style = p.Style.Border(lipgloss.RoundedBorder()).
 			BorderTop(false).BorderForeground(lipgloss.Color("#7D56F3")).
 			Padding(1).PaddingLeft(2).PaddingRight(2)
@@ -221,15 +330,22 @@ func FirstLineDefenseMiddleware() wish.Middleware {
 			user:               s.User(),
 			publicKey:          s.PublicKey(),
 			page:               page,
-			tabs:               []tab{{"Public Channels", publicChannelsView, []slack.Message{}, []slack.SearchMessage{}, "select", p, mi, 0}, {"Private Channels", privateChannelsView, []slack.Message{}, []slack.SearchMessage{}, "select", p, mi, 0}, {"DMs", directMessagesView, []slack.Message{}, []slack.SearchMessage{}, "select", p, mi, 0}, {"Search", searchView, []slack.Message{}, []slack.SearchMessage{}, "select", p, mi, 0}},
+			tabs:               []tab{newTab("Public Channels", publicChannelsView, p, ti, mi), newTab("Private Channels", privateChannelsView, p, ti, mi), newTab("DMs", directMessagesView, p, ti, mi), newTab("Search", searchView, p, ti, mi), newTab("Saved", savedView, p, ti, mi)},
 			channelList:        l,
 			privateChannelList: privateChannelL,
 			dmList:             dmL,
 			activeTab:          0,
 			slackClient:        slack.New(database.DB.ApplicationData[s.User()].SlackToken),
 			searchInput:        ti,
+			switcher:           switcher{input: ti},
+			output:             termenv.NewOutput(s),
+			unreads:            map[string]unreadCount{},
+			ctx:                s.Context(),
 		}
 
+		m.events = newEventSource(m.slackClient)
+		closeWithSession(m.ctx, m.events)
+
 		if database.EmojiCount() == 0 {
 			utils.GetEmojisFromSlack(*m.slackClient)
 			log.Info("loaded emojis", "count", database.EmojiCount())
@@ -244,11 +360,62 @@ type (
 	channelUpdateMessage        struct{ channels []slack.Channel }
 	privateChannelUpdateMessage struct{ channels []slack.Channel }
 	dmUpdateMessage             struct {
-		items []list.Item
+		names []string
 		dms   []slack.Channel
 	}
 )
 
+// use the shared socket mode stream when it's connected, otherwise poll history ourselves
+func newEventSource(slackClient *slack.Client) events.Source {
+	if events.DefaultHub.Live() {
+		return events.DefaultHub.NewSource()
+	}
+
+	return events.NewPoller(slackClient, 5*time.Second)
+}
+
+// stop polling once the ssh session goes away
+func closeWithSession(ctx context.Context, source events.Source) {
+	go func() {
+		<-ctx.Done()
+		source.Close()
+	}()
+}
+
+type messageEventUpdate struct{ event events.Event }
+
+// the source stopped, either the session ended or the shared connection dropped
+type eventsClosedUpdate struct{}
+
+// an event we caused ourselves, applied straight away instead of waiting for the stream
+type localEventUpdate struct{ event events.Event }
+
+func waitForEvent(source events.Source) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-source.Events()
+		if !ok {
+			return eventsClosedUpdate{}
+		}
+
+		return messageEventUpdate{event}
+	}
+}
+
+// the slack user id of whoever is logged in
+type identityUpdate string
+
+func getIdentity(slackClient *slack.Client) tea.Cmd {
+	return func() tea.Msg {
+		identity, err := slackClient.AuthTest()
+		if err != nil {
+			log.Error("error fetching identity", "err", err)
+			return errMsg{err}
+		}
+
+		return identityUpdate(identity.UserID)
+	}
+}
+
 type errMsg struct{ err error }
 
 func (e errMsg) Error() string { return e.err.Error() }
@@ -280,50 +447,44 @@ func getPrivateChannels(slackClient *slack.Client) tea.Cmd {
 func getDms(slackClient *slack.Client) tea.Cmd {
 	return func() tea.Msg {
 		// get the channels
-		dms, _, err := slackClient.GetConversationsForUser(&slack.GetConversationsForUserParameters{Limit: 10000, ExcludeArchived: true, Types: []string{"im"}})
+		dms, _, err := slackClient.GetConversationsForUser(&slack.GetConversationsForUserParameters{Limit: 10000, ExcludeArchived: true, Types: []string{"im", "mpim"}})
+		if err != nil {
+			return errMsg{err}
+		}
+
+		// group dms are named after everyone in them but us
+		identity, err := slackClient.AuthTest()
 		if err != nil {
 			return errMsg{err}
 		}
+		for i, dm := range dms {
+			if dm.IsMpIM && len(dm.Members) == 0 {
+				dms[i].Members = groupMembers(slackClient, dm.ID)
+			}
+		}
 
 		// sort dms by the priority field
 		slices.SortFunc(dms, func(a, b slack.Channel) int {
 			return -cmp.Compare(a.Priority, b.Priority)
 		})
 
-		items := []list.Item{}
+		names := []string{}
 		for _, dm := range dms {
-			name := "unknown"
-			if dm.IsIM {
-				// get the user's display name
-				user := database.GetUserOrCreate(dm.User, *slackClient)
-				if user.DisplayName == "" {
-					name = highlightedStyleBot.Render("@" + user.RealName + " (bot)")
-				} else {
-					name = highlightedStyle.Render("@" + user.DisplayName)
-				}
-			} else {
-				// get each participent in the conversation
-				for _, member := range dm.Members {
-					user := database.GetUserOrCreate(member, *slackClient)
-					if user.DisplayName == "" {
-						name += highlightedStyleBot.Render("@" + user.RealName + " (bot) ")
-					} else {
-						name += highlightedStyle.Render("@" + user.DisplayName + "")
-					}
-				}
-			}
-
-			items = append(items, item(name))
+			names = append(names, dmName(dm, identity.UserID, slackClient))
 		}
 
-		return dmUpdateMessage{items, dms}
+		return dmUpdateMessage{names, dms}
 	}
 }
 
 type tabMessageUpdate struct {
-	channel  string
-	messages []slack.Message
-	tab      int
+	channel    string
+	messages   []slack.Message
+	tab        int
+	nextCursor string
+	hasMore    bool
+	// the newest messages were left out, only set when loading around a message
+	partial bool
 }
 
 func getMessages(slackClient *slack.Client, channel string, tab int) tea.Cmd {
@@ -335,50 +496,39 @@ func getMessages(slackClient *slack.Client, channel string, tab int) tea.Cmd {
 			return errMsg{err}
 		}
 
-		return tabMessageUpdate{messages: messages.Messages, tab: tab, channel: channel}
-	}
-}
-
-type searchMessageUpdate struct {
-	messages []slack.SearchMessage
-}
-
-func searchMessages(slackClient *slack.Client, search string) tea.Cmd {
-	return func() tea.Msg {
-		messages, err := slackClient.SearchMessages(search, slack.SearchParameters{Count: 100})
-		if err != nil {
-			log.Error("error fetching messages", "err", err)
-
-			return errMsg{err}
-		}
-
-		return searchMessageUpdate{messages: messages.Matches}
+		return tabMessageUpdate{messages: messages.Messages, tab: tab, channel: channel, nextCursor: messages.ResponseMetaData.NextCursor, hasMore: messages.HasMore}
 	}
 }
 
 type backUpdate string
 
-func goBack() tea.Cmd {
+func goBack(state string) tea.Cmd {
 	return func() tea.Msg {
-		return backUpdate("select")
+		return backUpdate(state)
 	}
 }
 
-type sendMessageUpdate string
+// says where a message was sent from so only that composer gets cleared
+type sendMessageUpdate struct {
+	tab     int
+	channel string
+	// the thread it was a reply in, empty for the channel itself
+	thread string
+}
 
-func sendMessage(channel string, message string, slackClient slack.Client) tea.Cmd {
+func sendMessage(channel string, message string, slackClient slack.Client, tab int) tea.Cmd {
 	return func() tea.Msg {
 		_, _, err := slackClient.PostMessage(channel, slack.MsgOptionText(message, false))
 		if err != nil {
 			log.Error("error sending message", "err", err)
 			return errMsg{err}
 		}
-		return sendMessageUpdate("success")
+		return sendMessageUpdate{tab: tab, channel: channel}
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(getChannels(m.slackClient), getPrivateChannels(m.slackClient), getDms(m.slackClient), m.searchInput.Cursor.BlinkCmd())
+	return tea.Batch(getChannels(m.slackClient), getPrivateChannels(m.slackClient), getDms(m.slackClient), getIdentity(m.slackClient), getDirectory(m.slackClient, m.activeTab), m.searchInput.Cursor.BlinkCmd(), waitForEvent(m.events), scheduleUnreadRefresh(), schedulePresenceRefresh())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -389,13 +539,50 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.time = time.Time(msg)
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
-		m.width = msg.Width
+		// rendered bodies are wrapped to the old width so they all need drawing again
+		if msg.Width != m.width {
+			m.width = msg.Width
+			for i := range m.tabs {
+				m.tabs[i].rendered = map[string]string{}
+				if len(m.tabs[i].messages) > 0 {
+					m.refreshMessagePager(i)
+				}
+				if len(m.tabs[i].threadMessages) > 0 {
+					m.refreshThreadPager(i)
+				}
+			}
+		}
 	case tea.KeyMsg:
+		// the switcher sits over everything so it gets every key but quitting while it's open
+		if m.switcher.open {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			var cmd tea.Cmd
+			m, cmd = m.updateSwitcher(msg)
+			return m, cmd
+		}
+		// text fields keep ctrl+k for deleting to the end of the line
+		if key.Matches(msg, m.keys.Switcher) && m.page == "slack" && !m.isTyping() {
+			var cmd tea.Cmd
+			m, cmd = m.openSwitcher()
+			return m, cmd
+		}
+
+		if handled, cmd := m.updateCompletion(m.activeTab, msg); handled {
+			return m, cmd
+		}
+
 		switch {
-		case key.Matches(msg, m.keys.Help):
+		case key.Matches(msg, m.keys.Help) && !m.isTyping():
 			m.help.ShowAll = !m.help.ShowAll
-		case key.Matches(msg, m.keys.Quit):
+		case key.Matches(msg, m.keys.Quit) && (!m.isTyping() || msg.String() == "ctrl+c"):
 			return m, tea.Quit
+		case key.Matches(msg, m.keys.Preview):
+			if m.page == "slack" && m.tabs[m.activeTab].channel != "" {
+				m.tabs[m.activeTab].showPreview = !m.tabs[m.activeTab].showPreview
+				cmds = append(cmds, m.schedulePreview(m.activeTab))
+			}
 		case key.Matches(msg, m.keys.Enter):
 			// page specific logic
 			switch m.page {
@@ -412,8 +599,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// check if the user has a slack token
 				// if they do, redirect to home
 				if database.DB.ApplicationData[m.user].SlackToken != "" {
-					m.slackClient = slack.New(database.DB.ApplicationData[m.user].SlackToken)
+					// swap the token in place so the event source keeps using the same client
+					*m.slackClient = *slack.New(database.DB.ApplicationData[m.user].SlackToken)
 					m.page = "home"
+					cmds = append(cmds, getIdentity(m.slackClient))
 				}
 			case "home":
 				// redirect to slack page
@@ -423,41 +612,162 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, getDms(m.slackClient))
 			case "slack":
 				// check what page we are on
-				if m.activeTab == 3 {
-					m.tabs[m.activeTab].state = "view"
-					cmds = append(cmds, m.searchInput.Cursor.SetMode(cursor.CursorHide))
-					cmds = append(cmds, searchMessages(m.slackClient, m.searchInput.Value()))
+				if m.tabs[m.activeTab].state == "editStatus" {
+					cmds = append(cmds, m.saveStatusEditor(m.activeTab))
+				} else if m.activeTab == searchTab {
+					switch {
+					case m.tabs[searchTab].state == "select":
+						cmds = append(cmds, m.searchInput.Cursor.SetMode(cursor.CursorHide))
+						cmds = append(cmds, m.runSearch(1))
+					case m.tabs[searchTab].state == "view" && m.tabs[searchTab].searchForm.mode == filesMode:
+						cmds = append(cmds, m.openFileDetail())
+					case m.tabs[searchTab].state == "view":
+						cmds = append(cmds, m.openSearchResult())
+					}
+				} else if m.activeTab == savedTab {
+					cmds = append(cmds, m.openSavedItem())
 				} else {
-					channel := ""
-
-					switch m.activeTab {
-					case 0:
-						channel = m.channels[m.channelList.Index()].ID
-					case 1:
-						channel = m.privateChannels[m.privateChannelList.Index()].ID
-					case 2:
-						channel = m.dms[m.dmList.Index()].ID
+					// once a conversation is open it may not be the one highlighted in the list
+					channel := m.tabs[m.activeTab].channel
+
+					if m.tabs[m.activeTab].state == "select" {
+						switch m.activeTab {
+						case 0:
+							if i := m.channelList.Index(); i < len(m.channels) {
+								channel = m.channels[i].ID
+							}
+						case 1:
+							if i := m.privateChannelList.Index(); i < len(m.privateChannels) {
+								channel = m.privateChannels[i].ID
+							}
+						case 2:
+							if i := m.dmList.Index(); i < len(m.dms) {
+								channel = m.dms[i].ID
+							}
+						}
 					}
 
 					switch m.tabs[m.activeTab].state {
 					case "select":
+						if channel == "" {
+							break
+						}
 						// switch tab state to messages and run the get messages command
 						m.tabs[m.activeTab].state = "messages"
+						m.tabs[m.activeTab].channel = channel
+						m.recordVisit(channel)
+						m.restoreDraft(m.activeTab)
 						cmds = append(cmds, getMessages(m.slackClient, channel, m.activeTab))
 						m.tabs[m.activeTab].focused = 1
 						cmds = append(cmds, m.tabs[m.activeTab].messageInput.Focus())
 					case "messages":
-						// send the message
-						message := m.tabs[m.activeTab].messageInput.Value()
+						// enter on the pager picks the selected message, on the composer it sends
+						if m.tabs[m.activeTab].focused == 0 {
+							m.openActions(m.activeTab)
+							break
+						}
+
+						if cmd, ok := m.runSlashCommand(m.activeTab, m.tabs[m.activeTab].messageInput.Value()); ok {
+							cmds = append(cmds, cmd)
+							break
+						}
+
+						message, ok := m.encodeOutgoing(m.activeTab, m.tabs[m.activeTab].messageInput.Value())
+						if !ok {
+							break
+						}
+
+						if m.tabs[m.activeTab].editTimestamp != "" {
+							log.Info("editing a message", "channel", channel)
+							cmds = append(cmds, m.editMessage(m.activeTab, message))
+							break
+						}
 
 						log.Info("sending a message", "channel", channel)
-						cmds = append(cmds, sendMessage(channel, message, *m.slackClient))
+						cmds = append(cmds, sendMessage(channel, message, *m.slackClient, m.activeTab))
+					case "browse":
+						cmds = append(cmds, m.joinSelectedChannel(m.activeTab))
+					case "createChannel":
+						cmds = append(cmds, m.createBrowsedChannel(m.activeTab))
+					case "editInfo":
+						cmds = append(cmds, m.saveChannelDetail(m.activeTab))
+					case "profile":
+						cmds = append(cmds, m.messageProfileUser(m.activeTab))
+					case "pickUsers":
+						cmds = append(cmds, m.openPickedDM(m.activeTab))
+					case "actions":
+						cmds = append(cmds, m.runSelectedAction(m.activeTab))
+					case "confirmDelete":
+						cmds = append(cmds, m.deleteConfirmedMessage(m.activeTab))
+					case "react":
+						cmds = append(cmds, m.toggleReaction(m.activeTab))
+					case "thread":
+						if cmd, ok := m.runSlashCommand(m.activeTab, m.tabs[m.activeTab].messageInput.Value()); ok {
+							cmds = append(cmds, cmd)
+							break
+						}
+
+						reply, ok := m.encodeOutgoing(m.activeTab, m.tabs[m.activeTab].messageInput.Value())
+						if !ok {
+							break
+						}
+
+						t := m.tabs[m.activeTab]
+						log.Info("replying in a thread", "channel", t.channel, "thread", t.threadTimestamp)
+						cmds = append(cmds, sendThreadReply(t.channel, t.threadTimestamp, reply, t.alsoSendToChannel, *m.slackClient, m.activeTab))
 					}
 				}
 			}
 		case key.Matches(msg, m.keys.Back):
 			if m.page == "slack" {
-				cmds = append(cmds, goBack())
+				// threads back out to their channel, everything else to the list
+				switch {
+				case m.tabs[m.activeTab].state == "actions", m.tabs[m.activeTab].state == "react", m.tabs[m.activeTab].state == "confirmDelete", m.tabs[m.activeTab].state == "editInfo", m.tabs[m.activeTab].state == "profile":
+					cmds = append(cmds, goBack("messages"))
+				case m.tabs[m.activeTab].editTimestamp != "":
+					m.tabs[m.activeTab].editTimestamp = ""
+					m.restoreDraft(m.activeTab)
+				case m.tabs[m.activeTab].state == "thread":
+					cmds = append(cmds, goBack("messages"))
+				case m.tabs[m.activeTab].state == "createChannel":
+					cmds = append(cmds, m.openBrowser(m.activeTab))
+				case m.tabs[m.activeTab].state == "fileDetail":
+					cmds = append(cmds, goBack("view"))
+				case m.tabs[m.activeTab].state == "editStatus":
+					cmds = append(cmds, goBack(m.tabs[m.activeTab].statusEditor.returnState))
+				default:
+					cmds = append(cmds, goBack("select"))
+				}
+			}
+		case key.Matches(msg, m.keys.Thread):
+			if m.page == "slack" && m.tabs[m.activeTab].state == "messages" {
+				if message, ok := m.tabs[m.activeTab].selectedMessage(); ok {
+					cmds = append(cmds, m.openThread(m.activeTab, message))
+				}
+			}
+		case key.Matches(msg, m.keys.Browse):
+			if m.page == "slack" && (m.activeTab == 0 || m.activeTab == 1) {
+				switch m.tabs[m.activeTab].state {
+				case "select":
+					cmds = append(cmds, m.openBrowser(m.activeTab))
+				case "browse":
+					cmds = append(cmds, m.openCreateChannel(m.activeTab))
+				}
+			}
+			if m.page == "slack" && m.activeTab == 2 && m.tabs[m.activeTab].state == "select" {
+				cmds = append(cmds, m.openUserPicker(m.activeTab))
+			}
+		case key.Matches(msg, m.keys.Info):
+			if m.page == "slack" && m.tabs[m.activeTab].state == "messages" {
+				cmds = append(cmds, m.toggleInfoPanel(m.activeTab))
+			}
+		case key.Matches(msg, m.keys.Status):
+			if m.page == "slack" && m.tabs[m.activeTab].state == "select" {
+				cmds = append(cmds, m.openStatusEditor(m.activeTab))
+			}
+		case key.Matches(msg, m.keys.AlsoSend):
+			if m.page == "slack" && m.tabs[m.activeTab].state == "thread" {
+				m.tabs[m.activeTab].alsoSendToChannel = !m.tabs[m.activeTab].alsoSendToChannel
 			}
 		case key.Matches(msg, m.keys.Tab):
 			if m.page == "slack" {
@@ -467,10 +777,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if m.activeTab == 3 {
 						m.tabs[m.activeTab].state = "select"
 						cmds = append(cmds, m.searchInput.Cursor.SetMode(cursor.CursorBlink))
-						cmds = append(cmds, m.searchInput.Focus())
+						cmds = append(cmds, m.focusSearchField(0))
 					}
-				case "view", "messages":
+					if m.activeTab == savedTab {
+						cmds = append(cmds, m.openSaved())
+					}
+				case "view", "messages", "thread":
 					m.tabs[m.activeTab].focused = (m.tabs[m.activeTab].focused + 1) % 2
+				case "createChannel":
+					m.tabs[m.activeTab].createPrivate = !m.tabs[m.activeTab].createPrivate
+				case "editStatus":
+					cmds = append(cmds, m.focusStatusField(m.activeTab, 1))
+				case "pickUsers":
+					m.togglePicked(m.activeTab)
 				}
 			}
 		case key.Matches(msg, m.keys.ShiftTab):
@@ -478,117 +797,282 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch m.tabs[m.activeTab].state {
 				case "select":
 					m.activeTab = (m.activeTab - 1 + len(m.tabs)) % len(m.tabs)
+					if m.activeTab == savedTab {
+						cmds = append(cmds, m.openSaved())
+					}
 				case "view":
 					m.tabs[m.activeTab].focused = (m.tabs[m.activeTab].focused + 1) % 2
+				case "editStatus":
+					cmds = append(cmds, m.focusStatusField(m.activeTab, -1))
 				}
 			}
 		}
 	case channelUpdateMessage:
 		m.channels = msg.channels
-		items := []list.Item{}
-		for _, channel := range m.channels {
-			items = append(items, item(channel.Name))
-		}
-		m.channelList.SetItems(items)
+		m.refreshConversationLists()
+		cmds = append(cmds, m.firstUnreadBatch())
 	case privateChannelUpdateMessage:
 		m.privateChannels = msg.channels
-		items := []list.Item{}
-		for _, channel := range m.privateChannels {
-			items = append(items, item(channel.Name))
-		}
-		m.privateChannelList.SetItems(items)
+		m.refreshConversationLists()
+		cmds = append(cmds, m.firstUnreadBatch())
 	case dmUpdateMessage:
 		m.dms = msg.dms
-		m.dmList.SetItems(msg.items)
-	case tabMessageUpdate:
-		m.tabs[msg.tab].messages = msg.messages
-		// message content
-		var b strings.Builder
-		for _, message := range msg.messages {
-			creatorDisplayName := ""
-			user := database.GetUserOrCreate(message.User, *m.slackClient)
-			if user.DisplayName == "" {
-				creatorDisplayName = highlightedStyleBot.Render("@" + user.RealName + " (bot)")
-			} else {
-				creatorDisplayName += highlightedStyle.Render("@" + user.DisplayName)
-			}
-
-			i, err := strconv.ParseInt(strings.Split(message.Timestamp, ".")[0], 10, 64)
-			if err != nil {
-				panic(err)
+		m.dmNames = msg.names
+		m.refreshConversationLists()
+		cmds = append(cmds, m.firstUnreadBatch())
+		cmds = append(cmds, getPresence(m.slackClient, m.presenceUsers(), false))
+	case unreadTick:
+		if batch := m.nextUnreadBatch(); len(batch) > 0 && m.userID != "" {
+			cmds = append(cmds, getUnreads(m.slackClient, batch, m.userID, true))
+		} else {
+			cmds = append(cmds, scheduleUnreadRefresh())
+		}
+	case unreadUpdate:
+		for channel, count := range msg.counts {
+			// whatever is open on screen has been read, even if slack hasn't caught up yet
+			if m.isOpen(channel) {
+				continue
 			}
-			tm := time.Unix(i, 0)
-			messageString := mutedStyle.Render("\n  ---") + lessMutedStyle.Render("\n  time: ") + evenLessMutedStyle.Render(tm.Format(time.DateTime)) + lessMutedStyle.Render("\n  sender: ") + creatorDisplayName + mutedStyle.Render("\n  ---\n")
-
-			glamR, _ := glamour.NewTermRenderer(
-				glamour.WithWordWrap(m.width-18),
-				glamour.WithStylePath("styles/overgrown.json"),
-				glamour.WithPreservedNewLines(),
-			)
-
-			glamString, _ := glamR.Render(utils.UrlParser(message.Text))
-
-			messageString += glamString
-
-			messageString = utils.UserIdParser(messageString, highlightedStyle, highlightedStyleBot, *m.slackClient)
-
-			messageString = utils.EmojiParser(messageString)
-
-			b.WriteString(messageStyle.Width(m.width-12).Render(messageString) + "\n\n")
+			m.unreads[channel] = count
 		}
-
-		if len(msg.messages) == 0 {
-			b.WriteString(lipgloss.NewStyle().Width(m.width - 12).Align(lipgloss.Center).Render("no message found :("))
+		m.refreshConversationLists()
+		if msg.scheduled {
+			cmds = append(cmds, scheduleUnreadRefresh())
 		}
-
-		m.tabs[msg.tab].messagePager.SetContent(b.String())
-	case searchMessageUpdate:
-		m.tabs[3].searchMessages = msg.messages
-		// message content
-		var b strings.Builder
-		for _, message := range msg.messages {
-			creatorDisplayName := ""
-			user := database.GetUserOrCreate(message.User, *m.slackClient)
-			if user.DisplayName == "" {
-				creatorDisplayName = highlightedStyleBot.Render("@" + user.RealName + " (bot)")
-			} else {
-				creatorDisplayName += highlightedStyle.Render("@" + user.DisplayName)
-			}
-
-			i, err := strconv.ParseInt(strings.Split(message.Timestamp, ".")[0], 10, 64)
-			if err != nil {
-				panic(err)
+	case tabMessageUpdate:
+		t := &m.tabs[msg.tab]
+		// the tab moved on to another conversation while this was loading
+		if t.channel != msg.channel {
+			break
+		}
+		// slack hands history back newest first but we read top to bottom
+		t.messages = slices.Clone(msg.messages)
+		slices.Reverse(t.messages)
+		t.rendered = map[string]string{}
+		t.cursor = max(len(t.messages)-1, 0)
+		t.historyCursor = msg.nextCursor
+		t.historyLoading = false
+		t.historyComplete = !msg.hasMore
+		// live updates would land after a gap, so a partial load waits until it's opened again
+		if !msg.partial {
+			m.events.Subscribe(msg.channel, msg.messages)
+		}
+		database.IndexMessages(m.user, msg.channel, msg.messages)
+		m.refreshMessagePager(msg.tab)
+		t.messagePager.GotoBottom()
+		m.jumpToMessage(msg.tab)
+		if msg.partial {
+			t.status = "newer messages aren't loaded, open the conversation again to catch up"
+		} else if len(msg.messages) > 0 {
+			cmds = append(cmds, m.markConversationRead(msg.channel, msg.messages[0].Timestamp))
+		}
+	case olderMessagesUpdate:
+		database.IndexMessages(m.user, msg.channel, msg.messages)
+		cmds = append(cmds, m.prependOlderMessages(msg))
+	case olderMessagesFailed:
+		m.olderMessagesFailed(msg)
+	case messageEventUpdate:
+		m.indexEvent(msg.event)
+		cmds = append(cmds, m.applyEventToTabs(msg.event)...)
+		cmds = append(cmds, waitForEvent(m.events))
+		// new messages in a conversation we're looking at are read as they arrive
+		if msg.event.Kind == events.MessageNew && m.isOpen(msg.event.Channel) {
+			cmds = append(cmds, m.markConversationRead(msg.event.Channel, msg.event.Message.Timestamp))
+		}
+	case eventsClosedUpdate:
+		if m.ctx.Err() != nil {
+			break
+		}
+		// the hub went down under us, poll the open conversations from what we already have
+		m.events = events.NewPoller(m.slackClient, 5*time.Second)
+		closeWithSession(m.ctx, m.events)
+		for _, t := range m.tabs {
+			if t.channel != "" {
+				seed := slices.Clone(t.messages)
+				slices.Reverse(seed)
+				m.events.Subscribe(t.channel, seed)
 			}
-			tm := time.Unix(i, 0)
-			messageString := mutedStyle.Render("\n  ---") + lessMutedStyle.Render("\n  time: ") + evenLessMutedStyle.Render(tm.Format(time.DateTime)) + lessMutedStyle.Render("\n  sender: ") + creatorDisplayName + lessMutedStyle.Render("\n  channel: ") + evenLessMutedStyle.Render(message.Channel.Name) + mutedStyle.Render("\n  ---\n")
-
-			glamR, _ := glamour.NewTermRenderer(
-				glamour.WithWordWrap(m.width-18),
-				glamour.WithStylePath("styles/overgrown.json"),
-				glamour.WithPreservedNewLines(),
-			)
-
-			glamString, _ := glamR.Render(utils.UrlParser(message.Text))
-
-			messageString += glamString
-
-			messageString = utils.UserIdParser(messageString, highlightedStyle, highlightedStyleBot, *m.slackClient)
-
-			b.WriteString(messageStyle.Width(m.width-12).Render(messageString) + "\n\n")
 		}
-
-		if len(msg.messages) == 0 {
-			b.WriteString(lipgloss.NewStyle().Width(m.width - 12).Align(lipgloss.Center).Render("no message found :("))
+		cmds = append(cmds, waitForEvent(m.events))
+	case localEventUpdate:
+		m.indexEvent(msg.event)
+		cmds = append(cmds, m.applyEventToTabs(msg.event)...)
+	case searchMessageUpdate:
+		m.applySearchResults(msg)
+	case searchFilesUpdate:
+		m.applySearchFiles(msg)
+	case filePreviewUpdate:
+		m.applyFilePreview(msg)
+	case presenceTick:
+		cmds = append(cmds, getPresence(m.slackClient, m.presenceUsers(), true))
+	case presenceUpdate:
+		if msg.changed {
+			m.refreshPresence()
+		}
+		if msg.scheduled {
+			cmds = append(cmds, schedulePresenceRefresh())
+		}
+	case ownStatusUpdate:
+		m.applyOwnStatus(msg)
+	case statusSavedUpdate:
+		t := &m.tabs[msg.tab]
+		presence := "active"
+		if t.statusEditor.away {
+			presence = "away"
+		}
+		database.SetSlackUserPresence(m.userID, presence)
+		m.refreshPresence()
+		t.status = ""
+		if t.state == "editStatus" {
+			t.state = t.statusEditor.returnState
+		}
+	case threadMessageUpdate:
+		t := &m.tabs[msg.tab]
+		if t.state == "thread" && t.threadTimestamp == msg.timestamp {
+			database.IndexMessages(m.user, t.channel, msg.messages)
+			t.threadMessages = msg.messages
+			m.refreshThreadPager(msg.tab)
 		}
-
-		m.tabs[3].messagePager.SetContent(b.String())
 	case backUpdate:
-		m.tabs[m.activeTab].state = string(msg)
+		t := &m.tabs[m.activeTab]
+		switch {
+		case t.state == "thread":
+			t.threadTimestamp = ""
+			t.threadMessages = nil
+			t.alsoSendToChannel = false
+			m.restoreDraft(m.activeTab)
+		case string(msg) == "select":
+			if t.channel != "" {
+				m.events.Unsubscribe(t.channel)
+				t.channel = ""
+			}
+			t.status = ""
+			if t.showInfo {
+				t.showInfo = false
+				t.messagePager.Width = m.width - 4
+			}
+			// the draft is already saved, the composer starts empty for the next conversation
+			t.messageInput.SetValue("")
+			t.editTimestamp = ""
+			m.refreshCompletions(m.activeTab)
+			m.refreshConversationLists()
+			if m.activeTab == searchTab {
+				cmds = append(cmds, m.searchInput.Cursor.SetMode(cursor.CursorBlink))
+			}
+		}
+		t.state = string(msg)
 	case *tea.WindowSizeMsg:
 		m.tabs[m.activeTab].messagePager.Width = msg.Width - 4
 		m.tabs[m.activeTab].messagePager.Height = msg.Height - 4 - 2
+	case statusUpdate:
+		m.tabs[msg.tab].status = msg.text
+	case profileUpdate:
+		if t := &m.tabs[msg.tab]; t.state == "profile" && t.profileUser == msg.user {
+			t.profileAvatar = msg.avatar
+		}
+	case directoryUpdate:
+		m.directory = msg.users
+		if t := &m.tabs[msg.tab]; t.state == "pickUsers" {
+			t.status = ""
+		}
+	case dmOpenedUpdate:
+		cmds = append(cmds, m.openDM(msg))
+	case savedItemsUpdate:
+		m.applySavedItems(msg)
+	case messageFlagUpdate:
+		m.applyMessageFlag(msg)
+	case channelInfoUpdate:
+		if t := &m.tabs[msg.tab]; t.channel == msg.info.channel.ID {
+			t.info = &msg.info
+		}
+	case membersUpdate:
+		if t := &m.tabs[msg.tab]; t.info != nil && t.info.channel.ID == msg.channel {
+			t.info.members = append(t.info.members, msg.members...)
+			t.info.membersCursor = msg.cursor
+		}
+	case channelDetailUpdate:
+		t := &m.tabs[msg.tab]
+		if t.info != nil && t.info.channel.ID == msg.channel.ID {
+			// the returned channel leaves out the member count so keep ours
+			msg.channel.NumMembers = t.info.channel.NumMembers
+			t.info.channel = msg.channel
+		}
+		t.status = ""
+		if t.state == "editInfo" {
+			t.state = "messages"
+		}
+	case browseChannelsUpdate:
+		t := &m.tabs[msg.tab]
+		t.browseChannels = msg.channels
+		if t.state == "browse" {
+			t.status = ""
+		}
+	case joinedChannelUpdate:
+		t := &m.tabs[msg.tab]
+		for i := range t.browseChannels {
+			if t.browseChannels[i].ID == msg.channel.ID {
+				t.browseChannels[i].IsMember = true
+			}
+		}
+		// straight into the channel, the lists catch up in the background
+		cmds = append(cmds, getChannels(m.slackClient), getPrivateChannels(m.slackClient))
+		cmds = append(cmds, m.openConversationIn(msg.tab, msg.channel.ID, "", ""))
+	case slashCommandUpdate:
+		t := &m.tabs[msg.tab]
+		if msg.err != nil {
+			// keep what was typed so it can be fixed up and tried again
+			t.status = "error: " + msg.err.Error()
+			break
+		}
+		t.messageInput.SetValue("")
+		t.status = msg.result.Status
+		m.refreshCompletions(msg.tab)
+		m.saveDraft(msg.tab)
+		if msg.result.ChannelsChanged {
+			cmds = append(cmds, getChannels(m.slackClient), getPrivateChannels(m.slackClient))
+		}
+		if msg.result.CloseConversation && msg.tab == m.activeTab {
+			cmds = append(cmds, goBack("select"))
+		}
+	case errMsg:
+		if m.page == "slack" {
+			m.tabs[m.activeTab].status = "error: " + msg.Error()
+		}
+	case editMessageUpdate:
+		m.tabs[m.activeTab].editTimestamp = ""
+		m.tabs[m.activeTab].status = ""
+		m.restoreDraft(m.activeTab)
+		cmds = append(cmds, m.applyEventToTabs(msg.event)...)
+	case previewUpdate:
+		m.renderPreview(msg)
+	case identityUpdate:
+		m.userID = string(msg)
+		cmds = append(cmds, m.firstUnreadBatch())
 	case sendMessageUpdate:
-		m.tabs[m.activeTab].messageInput.SetValue("")
+		t := &m.tabs[msg.tab]
+		if t.channel != msg.channel || t.threadTimestamp != msg.thread {
+			// moved on before it went through, what was saved as the draft is what got sent
+			if msg.thread == "" {
+				database.SetDraft(m.user, msg.channel, "")
+				m.refreshConversationLists()
+			}
+			break
+		}
+		t.messageInput.SetValue("")
+		t.editTimestamp = ""
+		t.status = ""
+		m.refreshCompletions(msg.tab)
+		m.saveDraft(msg.tab)
+		cmds = append(cmds, m.schedulePreview(msg.tab))
+		if t.state == "thread" {
+			cmds = append(cmds, getThread(m.slackClient, t.channel, t.threadTimestamp, msg.tab))
+		}
+	}
+
+	// the status editor can be opened from any tab so it gets its input first
+	if m.page == "slack" && m.tabs[m.activeTab].state == "editStatus" {
+		cmds = append(cmds, m.updateStatusEditor(m.activeTab, msg))
+		return m, tea.Batch(cmds...)
 	}
 
 	// check which tab the user is on
@@ -599,17 +1083,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			channelList, channelCmd := m.channelList.Update(msg)
 			m.channelList = channelList
 			cmds = append(cmds, channelCmd)
-		case "messages":
-			switch m.tabs[m.activeTab].focused {
-			case 0:
-				messagePager, messageCommand := m.tabs[0].messagePager.Update(msg)
-				m.tabs[0].messagePager = messagePager
-				cmds = append(cmds, messageCommand)
-			case 1:
-				messageInput, messageInputCommand := m.tabs[0].messageInput.Update(msg)
-				m.tabs[0].messageInput = messageInput
-				cmds = append(cmds, messageInputCommand)
-			}
+		default:
+			cmds = append(cmds, m.updateConversation(0, msg))
 		}
 	case 1:
 		switch m.tabs[1].state {
@@ -617,17 +1092,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			mpimList, mpimCmd := m.privateChannelList.Update(msg)
 			m.privateChannelList = mpimList
 			cmds = append(cmds, mpimCmd)
-		case "messages":
-			switch m.tabs[m.activeTab].focused {
-			case 0:
-				messagePager, messageCommand := m.tabs[1].messagePager.Update(msg)
-				m.tabs[1].messagePager = messagePager
-				cmds = append(cmds, messageCommand)
-			case 1:
-				messageInput, messageInputCommand := m.tabs[1].messageInput.Update(msg)
-				m.tabs[1].messageInput = messageInput
-				cmds = append(cmds, messageInputCommand)
-			}
+		default:
+			cmds = append(cmds, m.updateConversation(1, msg))
 		}
 	case 2:
 		switch m.tabs[2].state {
@@ -635,33 +1101,199 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			imList, imCmd := m.dmList.Update(msg)
 			m.dmList = imList
 			cmds = append(cmds, imCmd)
-		case "messages":
-			switch m.tabs[m.activeTab].focused {
-			case 0:
-				messagePager, messageCommand := m.tabs[2].messagePager.Update(msg)
-				m.tabs[2].messagePager = messagePager
-				cmds = append(cmds, messageCommand)
-			case 1:
-				messageInput, messageInputCommand := m.tabs[2].messageInput.Update(msg)
-				m.tabs[2].messageInput = messageInput
-				cmds = append(cmds, messageInputCommand)
-			}
+		default:
+			cmds = append(cmds, m.updateConversation(2, msg))
 		}
 	case 3:
 		switch m.tabs[3].state {
 		case "select":
-			searchInput, cmd := m.searchInput.Update(msg)
-			m.searchInput = searchInput
-			cmds = append(cmds, cmd)
+			cmds = append(cmds, m.updateSearchForm(msg))
 		case "view":
+			if keyMsg, ok := msg.(tea.KeyMsg); ok {
+				if handled, cmd := m.updateSearchResults(keyMsg); handled {
+					cmds = append(cmds, cmd)
+					break
+				}
+			}
 			messagePager, messageCommand := m.tabs[3].messagePager.Update(msg)
 			m.tabs[3].messagePager = messagePager
 			cmds = append(cmds, messageCommand)
 		}
+	case savedTab:
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			cmds = append(cmds, m.updateSaved(msg))
+		}
 	}
 	return m, tea.Batch(cmds...)
 }
 
+// hands an event to every tab that has its channel open
+func (m Model) applyEventToTabs(event events.Event) []tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range m.tabs {
+		t := &m.tabs[i]
+		if t.channel == "" || t.channel != event.Channel {
+			continue
+		}
+
+		// stick to the bottom as new messages come in if that's where we were
+		followNewest := t.messagePager.AtBottom() && t.cursor == len(t.messages)-1
+
+		switch {
+		case event.Kind == events.ThreadReply:
+			if t.applyThreadReply(event.Message) {
+				m.refreshThreadPager(i)
+			}
+		case t.state == "thread" && event.Message.Timestamp == t.threadTimestamp:
+			// polling only sees the parent change so go get the replies ourselves
+			t.applyEvent(event)
+			cmds = append(cmds, getThread(m.slackClient, t.channel, t.threadTimestamp, i))
+		default:
+			t.applyEvent(event)
+		}
+
+		if followNewest && event.Kind == events.MessageNew {
+			t.cursor = max(len(t.messages)-1, 0)
+			m.refreshMessagePager(i)
+			t.messagePager.GotoBottom()
+		} else {
+			m.refreshMessagePager(i)
+		}
+	}
+
+	return cmds
+}
+
+// folds a live event into the tab's copy of the conversation, oldest message first
+func (t *tab) applyEvent(event events.Event) {
+	index := slices.IndexFunc(t.messages, func(message slack.Message) bool {
+		return message.Timestamp == event.Message.Timestamp
+	})
+
+	switch event.Kind {
+	case events.MessageNew:
+		if index == -1 {
+			t.messages = append(t.messages, event.Message)
+		}
+	case events.MessageChanged:
+		if index != -1 {
+			t.messages[index] = event.Message
+			delete(t.rendered, event.Message.Timestamp)
+		}
+	case events.ReactionAdded, events.ReactionRemoved:
+		if index != -1 {
+			t.messages[index] = withReaction(t.messages[index], event.Reaction, event.User, event.Kind == events.ReactionAdded)
+			delete(t.rendered, event.Message.Timestamp)
+		}
+	case events.MessageDeleted:
+		if index != -1 {
+			t.messages = slices.Delete(t.messages, index, index+1)
+			delete(t.rendered, event.Message.Timestamp)
+			if index < t.cursor || t.cursor >= len(t.messages) {
+				t.cursor = max(t.cursor-1, 0)
+			}
+		}
+	}
+}
+
+func (m Model) renderMessage(message slack.Message) string {
+	return messageStyle.Width(m.width - 12).Render(m.renderMessageBody(message))
+}
+
+func (m Model) renderMessageBody(message slack.Message) string {
+	creatorDisplayName := ""
+	user := database.GetUserOrCreate(message.User, *m.slackClient)
+	if user.DisplayName == "" {
+		creatorDisplayName = highlightedStyleBot.Render("@" + user.RealName + " (bot)")
+	} else {
+		creatorDisplayName += highlightedStyle.Render("@" + user.DisplayName)
+	}
+	creatorDisplayName += presenceSuffix(user)
+
+	// a timestamp slack sent us that we can't read isn't worth taking the session down over
+	sent := "unknown time"
+	if i, err := strconv.ParseInt(strings.Split(message.Timestamp, ".")[0], 10, 64); err == nil {
+		sent = time.Unix(i, 0).Format(time.DateTime)
+	} else {
+		log.Warn("couldn't read a message timestamp", "timestamp", message.Timestamp, "err", err)
+	}
+	edited := ""
+	if message.Edited != nil {
+		edited = mutedStyle.Render(" (edited)")
+	}
+	if len(message.PinnedTo) > 0 {
+		edited += mutedStyle.Render(" (pinned)")
+	}
+	if message.IsStarred {
+		edited += mutedStyle.Render(" (saved)")
+	}
+	messageString := mutedStyle.Render("\n  ---") + lessMutedStyle.Render("\n  time: ") + evenLessMutedStyle.Render(sent) + edited + lessMutedStyle.Render("\n  sender: ") + creatorDisplayName + mutedStyle.Render("\n  ---\n")
+
+	messageString += m.renderMessageContent(message)
+
+	if len(message.Reactions) > 0 {
+		messageString += m.renderReactions(message) + "\n"
+	}
+
+	// only the parent carries the count, replies shown inside the thread don't need it
+	if message.ReplyCount > 0 {
+		replies := "replies"
+		if message.ReplyCount == 1 {
+			replies = "reply"
+		}
+		messageString += mutedStyle.Render(fmt.Sprintf("  %d %s (ctrl+t to open thread)", message.ReplyCount, replies)) + "\n"
+	}
+
+	messageString = utils.UserIdParser(messageString, highlightedStyle, highlightedStyleBot, *m.slackClient)
+
+	messageString = utils.EmojiParser(messageString)
+
+	return messageString
+}
+
+// rebuilds the pager content for a tab, only rendering messages it hasn't seen yet
+func (m Model) refreshMessagePager(tab int) {
+	t := &m.tabs[tab]
+	// the info panel takes its columns out of the messages
+	if t.showInfo {
+		m.width -= infoPanelWidth
+	}
+
+	var b strings.Builder
+	t.offsets = t.offsets[:0]
+	line := 0
+
+	if marker := historyMarker(*t); marker != "" && len(t.messages) > 0 {
+		marker = lipgloss.NewStyle().Width(m.width - 12).Align(lipgloss.Center).Render(marker)
+		line += lipgloss.Height(marker) + 1
+		b.WriteString(marker + "\n\n")
+	}
+
+	for i, message := range t.messages {
+		body, ok := t.rendered[message.Timestamp]
+		if !ok {
+			body = m.renderMessageBody(message)
+			t.rendered[message.Timestamp] = body
+		}
+
+		boxStyle := messageStyle
+		if i == t.cursor {
+			boxStyle = selectedMessageStyle
+		}
+		rendered := boxStyle.Width(m.width - 12).Render(body)
+
+		t.offsets = append(t.offsets, line)
+		line += lipgloss.Height(rendered) + 1
+		b.WriteString(rendered + "\n\n")
+	}
+
+	if len(t.messages) == 0 {
+		b.WriteString(lipgloss.NewStyle().Width(m.width - 12).Align(lipgloss.Center).Render("no message found :("))
+	}
+
+	t.messagePager.SetContent(b.String())
+}
+
 func (m Model) View() string {
 	fittedStyle := style.
 		Width(m.width - 2).
@@ -757,7 +1389,13 @@ func SlackView(fittedStyle lipgloss.Style, m Model) string {
 		Width(m.width - 6).
 		Height(m.height - lipgloss.Height(row) - 3)
 
-	doc.WriteString(m.tabs[m.activeTab].content(windowStyle, m))
+	if m.switcher.open {
+		doc.WriteString(switcherView(windowStyle, m))
+	} else if m.tabs[m.activeTab].state == "editStatus" {
+		doc.WriteString(statusEditorView(windowStyle, m))
+	} else {
+		doc.WriteString(m.tabs[m.activeTab].content(windowStyle, m))
+	}
 	return docStyle.Render(doc.String())
 }
 
@@ -810,18 +1448,12 @@ func publicChannelsView(style lipgloss.Style, m Model) string {
 		m.channelList.SetHeight(style.GetHeight() - 4)
 		m.channelList.SetWidth(style.GetWidth() - 8)
 
-		items := []list.Item{}
-		for _, channel := range m.channelList.Items() {
-			items = append(items, item(utils.ClampString(fmt.Sprintf("%v", channel), m.channelList.Width())))
-		}
-		m.channelList.SetItems(items)
+		m.channelList.SetItems(clampItems(m.channelList.Items(), m.channelList.Width()))
 
 		return style.Render(m.channelList.View())
-	case "messages":
-		return m.tabs[m.activeTab].messagePager.View() + "\n" + sendMessageView(m)
 	}
 
-	return ""
+	return conversationView(m)
 }
 
 func privateChannelsView(style lipgloss.Style, m Model) string {
@@ -830,18 +1462,12 @@ func privateChannelsView(style lipgloss.Style, m Model) string {
 		m.privateChannelList.SetHeight(style.GetHeight() - 4)
 		m.privateChannelList.SetWidth(style.GetWidth() - 8)
 
-		items := []list.Item{}
-		for _, channel := range m.privateChannelList.Items() {
-			items = append(items, item(utils.ClampString(fmt.Sprintf("%v", channel), m.privateChannelList.Width())))
-		}
-		m.privateChannelList.SetItems(items)
+		m.privateChannelList.SetItems(clampItems(m.privateChannelList.Items(), m.privateChannelList.Width()))
 
 		return style.Render(m.privateChannelList.View())
-	case "messages":
-		return m.tabs[m.activeTab].messagePager.View() + "\n" + sendMessageView(m)
 	}
 
-	return ""
+	return conversationView(m)
 }
 
 func directMessagesView(style lipgloss.Style, m Model) string {
@@ -850,34 +1476,70 @@ func directMessagesView(style lipgloss.Style, m Model) string {
 		m.dmList.SetHeight(style.GetHeight() - 4)
 		m.dmList.SetWidth(style.GetWidth() - 8)
 
-		items := []list.Item{}
-		for _, dm := range m.dmList.Items() {
-			items = append(items, item(utils.ClampString(fmt.Sprintf("%v", dm), m.dmList.Width())))
-		}
-		m.dmList.SetItems(items)
+		m.dmList.SetItems(clampItems(m.dmList.Items(), m.dmList.Width()))
 
 		return style.Render(m.dmList.View())
-	case "messages":
-		return m.tabs[m.activeTab].messagePager.View() + "\n" + sendMessageView(m)
 	}
 
-	return ""
+	return conversationView(m)
 }
 
 func searchView(style lipgloss.Style, m Model) string {
 	switch m.tabs[m.activeTab].state {
 	case "select":
-		text := "What do you want to search for?\n\n" +
-			m.searchInput.View()
-		return style.Render(text)
+		return searchFormView(style, m)
 	case "view":
-		return m.tabs[m.activeTab].messagePager.View()
+		if m.tabs[m.activeTab].searchForm.mode == filesMode {
+			return fileResultsView(style, m)
+		}
+		return m.tabs[m.activeTab].messagePager.View() + "\n" + statusView(m)
+	case "fileDetail":
+		return fileDetailView(style, m)
 	}
 
 	return ""
 }
 
+func conversationView(m Model) string {
+	switch m.tabs[m.activeTab].state {
+	case "messages":
+		return withInfoPanel(m, withCompletions(m.tabs[m.activeTab], pagerOrPreview(m, m.tabs[m.activeTab].messagePager))) + "\n" + statusView(m) + sendMessageView(m)
+	case "thread":
+		return threadView(m)
+	case "actions":
+		return actionsView(m)
+	case "confirmDelete":
+		return confirmDeleteView(m)
+	case "react":
+		return reactView(m)
+	case "browse":
+		return browseView(m)
+	case "createChannel":
+		return createChannelView(m)
+	case "editInfo":
+		return editInfoView(m)
+	case "profile":
+		return profileView(m)
+	case "pickUsers":
+		return userPickerView(m)
+	}
+
+	return ""
+}
+
+func statusView(m Model) string {
+	if m.tabs[m.activeTab].status == "" {
+		return ""
+	}
+
+	return lessMutedStyle.Render(m.tabs[m.activeTab].status) + "\n"
+}
+
 func sendMessageView(m Model) string {
+	if m.tabs[m.activeTab].editTimestamp != "" {
+		return lessMutedStyle.Render("editing message (ctrl+b to cancel)") + "\n" + m.tabs[m.activeTab].messageInput.View()
+	}
+
 	return m.tabs[m.activeTab].messageInput.View()
 }
 
@@ -0,0 +1,64 @@
+package bubbleViews
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+
+	"charming-slack/libs/slashCommands"
+)
+
+type slashCommandUpdate struct {
+	tab    int
+	result slashCommands.Result
+	err    error
+}
+
+// runs the composer's text as a slash command, the bool is false when it isn't one
+func (m Model) runSlashCommand(tab int, text string) (tea.Cmd, bool) {
+	t := m.tabs[tab]
+	if t.editTimestamp != "" || !slashCommands.IsCommand(text) {
+		return nil, false
+	}
+
+	name, args := slashCommands.Parse(text)
+	command, ok := slashCommands.Lookup(name)
+	if !ok {
+		return setStatus(tab, "there's no /"+name+" command, try /help"), true
+	}
+
+	ctx := slashCommands.Context{
+		Client:   m.slackClient,
+		UserID:   m.userID,
+		Channel:  t.channel,
+		Channels: append(slices.Clone(m.channels), m.privateChannels...),
+		Users:    m.directory,
+	}
+	if t.state == "thread" {
+		ctx.Thread = t.threadTimestamp
+	}
+
+	log.Info("running slash command", "command", name, "channel", t.channel)
+	return func() tea.Msg {
+		result, err := command.Run(ctx, args)
+		if err != nil {
+			log.Error("error running slash command", "command", name, "err", err)
+		}
+
+		return slashCommandUpdate{tab, result, err}
+	}, true
+}
+
+func commandCompletions(text string) []completion {
+	completions := []completion{}
+	for _, command := range slashCommands.Commands() {
+		if strings.HasPrefix(command.Name, text) {
+			label := highlightedStyle.Render(command.Usage) + " " + lessMutedStyle.Render(command.Description)
+			completions = append(completions, completion{label: label, token: "/" + command.Name})
+		}
+	}
+
+	return completions
+}
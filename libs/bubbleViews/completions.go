@@ -0,0 +1,261 @@
+package bubbleViews
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/slashCommands"
+	"charming-slack/libs/utils"
+)
+
+// how many suggestions the popup shows at once
+const maxCompletions = 6
+
+var completionBoxStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#7D56F3"))
+
+type completion struct {
+	label string
+	// what ends up in the message, slack wants ids for mentions and links
+	token string
+}
+
+// the line the composer's cursor is on and the cursor's position in it
+func cursorLine(input textarea.Model) ([]rune, int) {
+	lines := strings.Split(input.Value(), "\n")
+	row := input.Line()
+	if row >= len(lines) {
+		return nil, 0
+	}
+
+	line := []rune(lines[row])
+	info := input.LineInfo()
+	return line, min(info.StartColumn+info.ColumnOffset, len(line))
+}
+
+// the @, # or : word the composer's cursor sits at the end of, empty when there isn't one
+func completionQuery(input textarea.Model) string {
+	line, col := cursorLine(input)
+
+	// only complete at the end of a word, not while editing the middle of one
+	if col < len(line) && !unicode.IsSpace(line[col]) {
+		return ""
+	}
+
+	start := col
+	for start > 0 && !unicode.IsSpace(line[start-1]) {
+		start--
+	}
+	word := string(line[start:col])
+
+	switch {
+	// commands only count as the very first word of the message
+	case strings.HasPrefix(word, "/") && start == 0 && input.Line() == 0 && slashCommands.IsCommand(word):
+		return word
+	case strings.HasPrefix(word, "@"), strings.HasPrefix(word, "#"):
+		return word
+	// a closing colon means the emoji is already finished
+	case strings.HasPrefix(word, ":") && len(word) > 2 && !strings.HasSuffix(word, ":"):
+		return word
+	}
+
+	return ""
+}
+
+func (m Model) completionsFor(query string) []completion {
+	text := strings.ToLower(query[1:])
+
+	var completions []completion
+	switch query[0] {
+	case '/':
+		completions = commandCompletions(text)
+	case '@':
+		completions = m.userCompletions(text)
+	case '#':
+		completions = m.channelCompletions(text)
+	case ':':
+		for _, name := range emojiSuggestions(text) {
+			label := ":" + name + ":"
+			if glyph, ok := utils.StandardEmoji[name]; ok {
+				label = glyph + " " + label
+			}
+			completions = append(completions, completion{label: label, token: ":" + name + ":"})
+		}
+	}
+
+	if len(completions) > maxCompletions {
+		completions = completions[:maxCompletions]
+	}
+
+	return completions
+}
+
+// prefix matches come before names that only contain the text
+func rankMatches[T any](candidates []T, text string, names func(T) []string) []T {
+	prefixed := []T{}
+	contained := []T{}
+	for _, candidate := range candidates {
+		matched := 0
+		for _, name := range names(candidate) {
+			name = strings.ToLower(name)
+			switch {
+			case strings.HasPrefix(name, text):
+				matched = max(matched, 2)
+			case strings.Contains(name, text):
+				matched = max(matched, 1)
+			}
+		}
+
+		switch matched {
+		case 2:
+			prefixed = append(prefixed, candidate)
+		case 1:
+			contained = append(contained, candidate)
+		}
+	}
+
+	return append(prefixed, contained...)
+}
+
+// only people in the session's own workspace, nobody else's cached users
+func (m Model) userCompletions(text string) []completion {
+	completions := []completion{}
+	for _, special := range []string{"here", "channel"} {
+		if strings.HasPrefix(special, text) {
+			completions = append(completions, completion{label: "@" + special, token: "<!" + special + ">"})
+		}
+	}
+
+	users := slices.Clone(m.directory)
+	slices.SortFunc(users, func(a, b slack.User) int {
+		return strings.Compare(strings.ToLower(a.Profile.DisplayName+a.RealName), strings.ToLower(b.Profile.DisplayName+b.RealName))
+	})
+
+	users = rankMatches(users, text, func(user slack.User) []string {
+		return []string{user.Profile.DisplayName, user.RealName}
+	})
+	for _, user := range users {
+		label := highlightedStyle.Render("@"+user.Profile.DisplayName) + " " + lessMutedStyle.Render(user.RealName)
+		if user.Profile.DisplayName == "" {
+			label = highlightedStyleBot.Render("@" + user.RealName)
+			if user.IsBot {
+				label = highlightedStyleBot.Render("@" + user.RealName + " (bot)")
+			}
+		}
+		completions = append(completions, completion{label: label, token: "<@" + user.ID + ">"})
+	}
+
+	return completions
+}
+
+func (m Model) channelCompletions(text string) []completion {
+	channels := append(slices.Clone(m.channels), m.privateChannels...)
+	slices.SortFunc(channels, func(a, b slack.Channel) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	completions := []completion{}
+	for _, channel := range rankMatches(channels, text, func(c slack.Channel) []string { return []string{c.Name} }) {
+		completions = append(completions, completion{label: "#" + channel.Name, token: "<#" + channel.ID + "|" + channel.Name + ">"})
+	}
+
+	return completions
+}
+
+// works out what the popup should show after the composer changed
+func (m Model) refreshCompletions(tab int) {
+	t := &m.tabs[tab]
+
+	query := completionQuery(t.messageInput)
+	if query != t.completionQuery {
+		t.completionCursor = 0
+		t.completionDismissed = false
+	}
+	t.completionQuery = query
+
+	if query == "" || t.completionDismissed {
+		t.completions = nil
+		return
+	}
+
+	t.completions = m.completionsFor(query)
+}
+
+func (t tab) completing() bool {
+	return t.focused == 1 && len(t.completions) > 0 && (t.state == "messages" || t.state == "thread")
+}
+
+// the popup gets first go at the keys it uses while it's open
+func (m Model) updateCompletion(tab int, msg tea.KeyMsg) (bool, tea.Cmd) {
+	t := &m.tabs[tab]
+	if m.page != "slack" || !t.completing() {
+		return false, nil
+	}
+
+	switch msg.String() {
+	case "up":
+		t.completionCursor = (t.completionCursor - 1 + len(t.completions)) % len(t.completions)
+	case "down":
+		t.completionCursor = (t.completionCursor + 1) % len(t.completions)
+	case "esc":
+		t.completionDismissed = true
+		t.completions = nil
+	case "tab", "enter":
+		return true, m.acceptCompletion(tab)
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// swaps the typed word for the suggestion's token
+func (m Model) acceptCompletion(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	selected := t.completions[t.completionCursor]
+
+	for range []rune(t.completionQuery) {
+		t.messageInput, _ = t.messageInput.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	// no need for a space if the word was already followed by one
+	if line, col := cursorLine(t.messageInput); col < len(line) {
+		t.messageInput.InsertString(selected.token)
+	} else {
+		t.messageInput.InsertString(selected.token + " ")
+	}
+
+	m.refreshCompletions(tab)
+	return m.schedulePreview(tab)
+}
+
+// draws the popup over the bottom of whatever sits above the composer
+func withCompletions(t tab, view string) string {
+	if !t.completing() {
+		return view
+	}
+
+	items := []string{}
+	for i, c := range t.completions {
+		if i == t.completionCursor {
+			items = append(items, selectedItemStyle.Render("> "+c.label))
+		} else {
+			items = append(items, itemStyle.Render(c.label))
+		}
+	}
+	items = append(items, mutedStyle.Render("  tab to insert, esc to close"))
+
+	popup := strings.Split(completionBoxStyle.Render(strings.Join(items, "\n")), "\n")
+	lines := strings.Split(view, "\n")
+	if len(popup) > len(lines) {
+		return view
+	}
+
+	copy(lines[len(lines)-len(popup):], popup)
+	return strings.Join(lines, "\n")
+}
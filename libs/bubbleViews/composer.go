@@ -0,0 +1,144 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/utils"
+)
+
+// how many lines the composer takes up
+const composerHeight = 3
+
+// slack cuts messages off at 40k characters so there's no point letting you type more
+const composerCharLimit = 40000
+
+func newComposer(width int) textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "your message here"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = composerCharLimit
+	ta.MaxHeight = 0
+	ta.SetWidth(width)
+	ta.SetHeight(composerHeight)
+	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	// enter sends, most terminals can't tell shift+enter apart so alt+enter and ctrl+j work too
+	ta.KeyMap.InsertNewline.SetKeys("shift+enter", "alt+enter", "ctrl+j")
+	ta.Focus()
+
+	return ta
+}
+
+// whether keys should go to a text box rather than trigger shortcuts
+func (m Model) isTyping() bool {
+	if m.page != "slack" {
+		return false
+	}
+
+	if m.switcher.open {
+		return true
+	}
+
+	t := m.tabs[m.activeTab]
+	switch t.state {
+	case "messages", "thread":
+		return t.focused == 1
+	case "react", "browse", "createChannel", "editInfo", "editStatus", "pickUsers":
+		return true
+	case "select":
+		return m.activeTab == 3
+	}
+
+	return false
+}
+
+// turns typed names into real mentions before anything goes out, when a name is ambiguous
+// the first enter only warns and pressing it again sends the name as plain text
+func (m Model) encodeOutgoing(tab int, text string) (string, bool) {
+	t := &m.tabs[tab]
+
+	encoded, warnings := utils.EncodeMentions(text, m.directory, append(slices.Clone(m.channels), m.privateChannels...))
+	if len(warnings) == 0 || t.mentionWarning == text {
+		t.mentionWarning = ""
+		// a doubled slash sends a message that starts with one instead of running a command
+		if strings.HasPrefix(encoded, "//") {
+			encoded = encoded[1:]
+		}
+		return encoded, true
+	}
+
+	t.mentionWarning = text
+	t.status = strings.Join(warnings, ", ") + " (pick with @ or press enter again to send as is)"
+	return "", false
+}
+
+type previewUpdate struct {
+	tab     int
+	version int
+}
+
+// waits for typing to settle before rendering, emoji in the preview mean downloads
+func (m Model) schedulePreview(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	if !t.showPreview {
+		return nil
+	}
+
+	t.previewVersion++
+	version := t.previewVersion
+	return tea.Tick(300*time.Millisecond, func(time.Time) tea.Msg {
+		return previewUpdate{tab, version}
+	})
+}
+
+// renders the draft through the same pipeline as everyone else's messages
+func (m Model) renderPreview(msg previewUpdate) {
+	t := &m.tabs[msg.tab]
+	if !t.showPreview || msg.version != t.previewVersion {
+		return
+	}
+
+	if t.messageInput.Value() == "" {
+		t.previewPager.SetContent(mutedStyle.Render("start typing to see a preview"))
+		return
+	}
+
+	// encoded the same way sending does so mentions show up as whoever they'll notify
+	text, _ := utils.EncodeMentions(t.messageInput.Value(), m.directory, append(slices.Clone(m.channels), m.privateChannels...))
+	if strings.HasPrefix(text, "//") {
+		text = text[1:]
+	}
+
+	draft := slack.Message{Msg: slack.Msg{
+		User:      m.userID,
+		Text:      text,
+		Timestamp: fmt.Sprintf("%d.000000", time.Now().Unix()),
+	}}
+	t.previewPager.SetContent(m.renderMessage(draft))
+}
+
+// while previewing the conversation shares its space with the draft, the newest messages stay in view
+func pagerOrPreview(m Model, pager viewport.Model) string {
+	t := m.tabs[m.activeTab]
+	if !t.showPreview {
+		return pager.View()
+	}
+
+	top := pager
+	top.Height = max(pager.Height/2, 3)
+	top.SetYOffset(pager.YOffset + pager.Height - top.Height)
+
+	preview := t.previewPager
+	preview.Width = pager.Width
+	preview.Height = max(pager.Height-top.Height-1, 1)
+
+	return top.View() + "\n" + lessMutedStyle.Render("preview (ctrl+r to hide it)") + "\n" + preview.View()
+}
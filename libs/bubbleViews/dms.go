@@ -0,0 +1,244 @@
+package bubbleViews
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// sent once slack has found or made the dm we asked for
+type dmOpenedUpdate struct {
+	tab     int
+	channel slack.Channel
+	name    string
+}
+
+// opens the dm with these people, slack hands back the existing one if there already is one.
+// more than one person makes it a group dm
+func openDirectMessage(slackClient *slack.Client, users []string, self string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		channel, _, _, err := slackClient.OpenConversation(&slack.OpenConversationParameters{Users: users, ReturnIM: true})
+		if err != nil {
+			log.Error("error opening dm", "users", users, "err", err)
+			return statusUpdate{tab, "couldn't open the dm: " + err.Error()}
+		}
+
+		// we already know who's in it so there's no need to ask
+		if channel.IsMpIM && len(channel.Members) == 0 {
+			channel.Members = users
+		}
+
+		return dmOpenedUpdate{tab, *channel, dmName(*channel, self, slackClient)}
+	}
+}
+
+// conversation lists leave group dm members out so they have to be asked for
+func groupMembers(slackClient *slack.Client, channel string) []string {
+	members, _, err := slackClient.GetUsersInConversation(&slack.GetUsersInConversationParameters{ChannelID: channel, Limit: 20})
+	if err != nil {
+		log.Error("error fetching group dm members", "channel", channel, "err", err)
+		return nil
+	}
+
+	return members
+}
+
+func memberName(userID string, slackClient *slack.Client) string {
+	user := database.GetUserOrCreate(userID, *slackClient)
+	if user.DisplayName == "" {
+		return highlightedStyleBot.Render("@" + user.RealName + " (bot)")
+	}
+	return highlightedStyle.Render("@" + user.DisplayName)
+}
+
+// how a dm is named in the list, the other person for a one to one and everyone else in a group
+func dmName(dm slack.Channel, self string, slackClient *slack.Client) string {
+	if dm.IsIM {
+		return memberName(dm.User, slackClient)
+	}
+
+	names := []string{}
+	for _, member := range dm.Members {
+		if member != self {
+			names = append(names, memberName(member, slackClient))
+		}
+	}
+	if len(names) == 0 {
+		return "unknown"
+	}
+
+	return strings.Join(names, mutedStyle.Render(", "))
+}
+
+// a brand new dm goes to the top of the list so it's there when we open it
+func (m *Model) openDM(msg dmOpenedUpdate) tea.Cmd {
+	if !slices.ContainsFunc(m.dms, func(dm slack.Channel) bool { return dm.ID == msg.channel.ID }) {
+		m.dms = append([]slack.Channel{msg.channel}, m.dms...)
+		m.dmNames = append([]string{msg.name}, m.dmNames...)
+		m.refreshConversationLists()
+	}
+
+	// whatever we opened it from is done with
+	if t := &m.tabs[msg.tab]; t.state == "profile" {
+		t.state = "messages"
+	}
+
+	return m.openConversation(msg.channel.ID, "", "")
+}
+
+// slack won't make a group dm with more than this many other people
+const maxGroupMembers = 8
+
+type directoryUpdate struct {
+	tab   int
+	users []slack.User
+}
+
+// everyone in the session's own workspace, fetched once and used for the user picker,
+// completions and resolving typed names so nothing is looked up across workspaces
+func getDirectory(slackClient *slack.Client, tab int) tea.Cmd {
+	return func() tea.Msg {
+		users, err := slackClient.GetUsers(slack.GetUsersOptionLimit(200))
+		if err != nil {
+			log.Error("error fetching the directory", "err", err)
+			return statusUpdate{tab, "couldn't load the directory: " + err.Error()}
+		}
+
+		users = slices.DeleteFunc(users, func(user slack.User) bool {
+			return user.Deleted
+		})
+		slices.SortFunc(users, func(a, b slack.User) int {
+			return strings.Compare(strings.ToLower(a.RealName), strings.ToLower(b.RealName))
+		})
+
+		return directoryUpdate{tab, users}
+	}
+}
+
+func (m Model) openUserPicker(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.state = "pickUsers"
+	t.status = ""
+	t.browseCursor = 0
+	t.picked = nil
+	t.browseInput.Reset()
+	t.browseInput.Placeholder = "search people"
+
+	if m.directory == nil {
+		t.status = "loading people..."
+		return tea.Batch(t.browseInput.Focus(), getDirectory(m.slackClient, tab))
+	}
+
+	return t.browseInput.Focus()
+}
+
+// people we could start a dm with, bots and ourselves left out
+func (m Model) directoryResults(tab int) []slack.User {
+	people := slices.DeleteFunc(slices.Clone(m.directory), func(user slack.User) bool {
+		return user.IsBot || user.ID == "USLACKBOT" || user.ID == m.userID
+	})
+
+	query := strings.ToLower(strings.TrimPrefix(m.tabs[tab].browseInput.Value(), "@"))
+	if query == "" {
+		return people
+	}
+
+	return rankMatches(people, query, func(user slack.User) []string {
+		return []string{user.Profile.DisplayName, user.RealName, user.Name}
+	})
+}
+
+// adds or takes away the highlighted person from the group
+func (m Model) togglePicked(tab int) {
+	t := &m.tabs[tab]
+	results := m.directoryResults(tab)
+	if t.browseCursor >= len(results) {
+		return
+	}
+
+	user := results[t.browseCursor].ID
+	if i := slices.Index(t.picked, user); i != -1 {
+		t.picked = slices.Delete(t.picked, i, i+1)
+		return
+	}
+	if len(t.picked) >= maxGroupMembers {
+		t.status = "a group dm can only have " + strconv.Itoa(maxGroupMembers) + " other people"
+		return
+	}
+	t.picked = append(t.picked, user)
+}
+
+// opens a dm with everyone picked, or just the highlighted person if nobody was
+func (m Model) openPickedDM(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	users := t.picked
+	if len(users) == 0 {
+		results := m.directoryResults(tab)
+		if t.browseCursor >= len(results) {
+			return nil
+		}
+		users = []string{results[t.browseCursor].ID}
+	}
+
+	t.status = "opening the conversation..."
+	return openDirectMessage(m.slackClient, users, m.userID, tab)
+}
+
+func userPickerView(m Model) string {
+	t := m.tabs[m.activeTab]
+	results := m.directoryResults(m.activeTab)
+
+	var b strings.Builder
+	if m.directory != nil && len(results) == 0 {
+		b.WriteString(mutedStyle.Render("  nobody matches"))
+	}
+
+	rows := m.browseRows()
+	start := t.browseCursor / rows * rows
+	for i := start; i < min(start+rows, len(results)); i++ {
+		user := results[i]
+
+		check := "[ ] "
+		if slices.Contains(t.picked, user.ID) {
+			check = "[x] "
+		}
+		name := user.Profile.DisplayName
+		if name == "" {
+			name = user.RealName
+		}
+		about := user.RealName
+		if user.Profile.Title != "" {
+			about += " · " + user.Profile.Title
+		}
+		about = utils.ClampString(about, m.width-16)
+
+		if i == t.browseCursor {
+			b.WriteString(selectedItemStyle.Render("> "+check+"@"+name) + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(check+"@"+name) + "\n")
+		}
+		b.WriteString(itemStyle.Render(mutedStyle.Render("    "+about)) + "\n")
+	}
+
+	list := lipgloss.NewStyle().Height(rows * 2).Render(b.String())
+
+	with := ""
+	if len(t.picked) > 0 {
+		names := []string{}
+		for _, user := range t.picked {
+			names = append(names, memberName(user, m.slackClient))
+		}
+		with = lessMutedStyle.Render("with ") + strings.Join(names, mutedStyle.Render(", ")) + "\n"
+	}
+	hint := lessMutedStyle.Render("tab to add someone to a group, enter to open the conversation, ctrl+b to go back")
+
+	return list + "\n" + statusView(m) + with + hint + "\n" + t.browseInput.View()
+}
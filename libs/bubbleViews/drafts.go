@@ -0,0 +1,26 @@
+package bubbleViews
+
+import (
+	"charming-slack/libs/database"
+)
+
+// keeps the composer's text for the conversation as it's typed, replies and edits aren't drafts
+func (m Model) saveDraft(tab int) {
+	t := m.tabs[tab]
+	if t.channel == "" || t.state != "messages" || t.editTimestamp != "" {
+		return
+	}
+
+	database.SetDraft(m.user, t.channel, t.messageInput.Value())
+}
+
+// puts back whatever was left unsent in the conversation, even from another session
+func (m Model) restoreDraft(tab int) {
+	t := &m.tabs[tab]
+	t.messageInput.SetValue(database.GetDraft(m.user, t.channel))
+	m.refreshCompletions(tab)
+}
+
+func (m Model) hasDraft(channel string) bool {
+	return database.GetDraft(m.user, channel) != ""
+}
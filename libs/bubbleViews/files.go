@@ -0,0 +1,282 @@
+package bubbleViews
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// how wide an image preview is drawn in pixels
+const filePreviewWidth = 360
+
+// anything bigger isn't worth downloading just to show the start of it
+const maxTextPreview = 64 * 1024
+
+const filePreviewLines = 20
+
+type searchFilesUpdate struct {
+	files []slack.File
+	page  int
+	pages int
+	total int
+}
+
+func searchFiles(slackClient *slack.Client, query string, sort string, page int) tea.Cmd {
+	return func() tea.Msg {
+		files, err := slackClient.SearchFiles(query, slack.SearchParameters{Count: searchPageSize, Page: page, Sort: sort, SortDirection: "desc"})
+		if err != nil {
+			log.Error("error searching files", "err", err)
+
+			return statusUpdate{searchTab, "search failed: " + err.Error()}
+		}
+		// the list draws uploaders from the cache
+		for _, file := range files.Matches {
+			utils.CacheUsers(*slackClient, file.User, "")
+		}
+
+		return searchFilesUpdate{files: files.Matches, page: files.Paging.Page, pages: files.Paging.Pages, total: files.Pagination.TotalCount}
+	}
+}
+
+// an image comes back already drawn, text comes back as it is
+type filePreviewUpdate struct {
+	file  string
+	image string
+	text  string
+}
+
+func isImage(file slack.File) bool {
+	return strings.HasPrefix(file.Mimetype, "image/")
+}
+
+func isText(file slack.File) bool {
+	return file.Mode == "snippet" || strings.HasPrefix(file.Mimetype, "text/") || file.Preview != ""
+}
+
+// someone else's file shouldn't be able to send escape sequences to our terminal
+func sanitizePreview(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// a fence longer than any run of backticks in the text so nothing in it can close the block early
+func codeFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// draws images and shows the start of text files, private downloads need the client's token
+func getFilePreview(slackClient *slack.Client, file slack.File) tea.Cmd {
+	return func() tea.Msg {
+		switch {
+		case isImage(file):
+			url := file.Thumb480
+			if url == "" {
+				url = file.URLPrivate
+			}
+
+			var buf bytes.Buffer
+			if err := slackClient.GetFile(url, &buf); err != nil {
+				log.Error("error downloading image", "file", file.ID, "err", err)
+				return statusUpdate{searchTab, "couldn't load the preview: " + err.Error()}
+			}
+
+			return filePreviewUpdate{file: file.ID, image: utils.SixelEncodeReader(&buf, filePreviewWidth)}
+		case isText(file):
+			text := file.Preview
+			if text == "" && file.Size <= maxTextPreview {
+				var buf bytes.Buffer
+				if err := slackClient.GetFile(file.URLPrivateDownload, &buf); err != nil {
+					log.Error("error downloading file", "file", file.ID, "err", err)
+					return statusUpdate{searchTab, "couldn't load the preview: " + err.Error()}
+				}
+				text = buf.String()
+			}
+
+			lines := strings.Split(sanitizePreview(text), "\n")
+			if len(lines) > filePreviewLines {
+				lines = append(lines[:filePreviewLines], "...")
+			}
+
+			return filePreviewUpdate{file: file.ID, text: strings.Join(lines, "\n")}
+		}
+
+		return filePreviewUpdate{file: file.ID}
+	}
+}
+
+func (m Model) applySearchFiles(msg searchFilesUpdate) {
+	t := &m.tabs[searchTab]
+	t.searchFiles = msg.files
+	t.searchPage = msg.page
+	t.searchPages = msg.pages
+	t.searchTotal = msg.total
+	t.searchCursor = 0
+	t.status = ""
+}
+
+func (m Model) updateSearchFiles(msg tea.KeyMsg) {
+	t := &m.tabs[searchTab]
+	switch msg.String() {
+	case "up", "k":
+		t.searchCursor = max(t.searchCursor-1, 0)
+	case "down", "j":
+		t.searchCursor = max(min(t.searchCursor+1, len(t.searchFiles)-1), 0)
+	}
+}
+
+func (m Model) openFileDetail() tea.Cmd {
+	t := &m.tabs[searchTab]
+	if t.searchCursor >= len(t.searchFiles) {
+		return nil
+	}
+
+	file := t.searchFiles[t.searchCursor]
+	t.state = "fileDetail"
+	t.filePreview = ""
+	if !isImage(file) && !isText(file) {
+		return nil
+	}
+
+	t.status = "loading the preview..."
+	return getFilePreview(m.slackClient, file)
+}
+
+func (m Model) applyFilePreview(msg filePreviewUpdate) {
+	t := &m.tabs[searchTab]
+	if t.state != "fileDetail" || t.searchCursor >= len(t.searchFiles) || t.searchFiles[t.searchCursor].ID != msg.file {
+		return
+	}
+
+	t.status = ""
+	t.filePreview = msg.image
+	if msg.text == "" {
+		return
+	}
+
+	// highlighted as whatever language slack thinks it is
+	fence := codeFence(msg.text)
+	t.filePreview = m.renderMarkdown(fence+t.searchFiles[t.searchCursor].Filetype+"\n"+msg.text+"\n"+fence, m.width-18)
+}
+
+// everywhere the file was shared
+func (m Model) fileChannels(file slack.File) []string {
+	names := []string{}
+	for _, channels := range [][]string{file.Channels, file.Groups, file.IMs} {
+		for _, channel := range channels {
+			names = append(names, m.conversationName(channel))
+		}
+	}
+
+	return names
+}
+
+func fileTitle(file slack.File) string {
+	if file.Title != "" {
+		return file.Title
+	}
+	return file.Name
+}
+
+func fileResultsView(style lipgloss.Style, m Model) string {
+	t := m.tabs[searchTab]
+
+	var b strings.Builder
+	if len(t.searchFiles) == 0 {
+		if t.status == "" {
+			b.WriteString(mutedStyle.Render("  no files found :("))
+		}
+	} else {
+		b.WriteString("  " + searchSummary(t) + "\n\n")
+	}
+
+	// each file takes two lines, scroll a page at a time to keep the cursor in view
+	rows := max((style.GetHeight()-6)/2, 1)
+	start := t.searchCursor / rows * rows
+	for i := start; i < min(start+rows, len(t.searchFiles)); i++ {
+		file := t.searchFiles[i]
+
+		uploader := database.QuerySlackUserID(file.User)
+		name := uploader.DisplayName
+		if name == "" {
+			name = uploader.RealName
+		}
+
+		details := []string{file.PrettyType, "@" + name}
+		if channels := m.fileChannels(file); len(channels) > 0 {
+			details = append(details, channels[0])
+		}
+		details = append(details, file.Created.Time().Format(time.DateTime))
+		about := utils.ClampString(strings.Join(details, " · "), m.width-16)
+
+		if i == t.searchCursor {
+			b.WriteString(selectedItemStyle.Render("> "+fileTitle(file)) + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(fileTitle(file)) + "\n")
+		}
+		b.WriteString(itemStyle.Render(mutedStyle.Render("  "+about)) + "\n")
+	}
+
+	list := lipgloss.NewStyle().Height(rows*2 + 2).Render(b.String())
+	hint := lessMutedStyle.Render("enter to look at a file, ctrl+b to change the search")
+
+	return style.Render(list + "\n" + statusView(m) + hint)
+}
+
+func fileDetailView(style lipgloss.Style, m Model) string {
+	t := m.tabs[searchTab]
+	if t.searchCursor >= len(t.searchFiles) {
+		return ""
+	}
+	file := t.searchFiles[t.searchCursor]
+
+	row := func(label string, value string) string {
+		if value == "" {
+			return ""
+		}
+		return itemStyle.Render(lessMutedStyle.Render(label+" ")+evenLessMutedStyle.Render(value)) + "\n"
+	}
+
+	uploader := database.QuerySlackUserID(file.User)
+
+	var b strings.Builder
+	b.WriteString(itemStyle.Render(highlightedStyle.Render(fileTitle(file))) + "\n\n")
+	b.WriteString(row("name", file.Name))
+	b.WriteString(row("type", file.PrettyType+" · "+humanSize(file.Size)))
+	b.WriteString(itemStyle.Render(lessMutedStyle.Render("uploaded by ")+userName(file.User)+presenceSuffix(uploader)) + "\n")
+	b.WriteString(row("shared in", strings.Join(m.fileChannels(file), ", ")))
+	b.WriteString(row("date", file.Created.Time().Format(time.DateTime)))
+	b.WriteString(row("link", file.Permalink))
+
+	if t.filePreview != "" {
+		b.WriteString("\n" + itemStyle.Render(t.filePreview) + "\n")
+	}
+
+	return style.Render(b.String() + "\n" + statusView(m) + lessMutedStyle.Render("ctrl+b to go back to the results"))
+}
@@ -0,0 +1,153 @@
+package bubbleViews
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+)
+
+type olderMessagesUpdate struct {
+	channel    string
+	messages   []slack.Message
+	tab        int
+	nextCursor string
+	hasMore    bool
+}
+
+// the page didn't come back, the tab stops loading so scrolling up again retries it
+type olderMessagesFailed struct {
+	channel string
+	tab     int
+	err     error
+}
+
+func getOlderMessages(slackClient *slack.Client, channel string, cursor string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		messages, err := slackClient.GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: channel, Cursor: cursor, Limit: 100})
+		if err != nil {
+			log.Error("error fetching older messages", "err", err)
+
+			return olderMessagesFailed{channel: channel, tab: tab, err: err}
+		}
+
+		return olderMessagesUpdate{messages: messages.Messages, tab: tab, channel: channel, nextCursor: messages.ResponseMetaData.NextCursor, hasMore: messages.HasMore}
+	}
+}
+
+// shown above the oldest message we have
+func historyMarker(t tab) string {
+	switch {
+	case t.historyLoading:
+		return mutedStyle.Render("loading older messages...")
+	case t.historyComplete:
+		return mutedStyle.Render("~ beginning of channel ~")
+	}
+
+	return ""
+}
+
+// kicks off the next page of history once the pager is scrolled all the way up
+func (m Model) loadOlderIfAtTop(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	if t.state != "messages" || t.messagePager.YOffset > 0 || t.historyLoading || t.historyComplete || t.historyCursor == "" {
+		return nil
+	}
+
+	t.historyLoading = true
+	m.refreshMessagePager(tab)
+
+	return getOlderMessages(m.slackClient, t.channel, t.historyCursor, tab)
+}
+
+func (m Model) olderMessagesFailed(msg olderMessagesFailed) {
+	t := &m.tabs[msg.tab]
+	if t.channel != msg.channel {
+		return
+	}
+
+	t.historyLoading = false
+	t.status = "couldn't load older messages: " + msg.err.Error()
+	m.refreshMessagePager(msg.tab)
+}
+
+func (m Model) prependOlderMessages(msg olderMessagesUpdate) tea.Cmd {
+	t := &m.tabs[msg.tab]
+	if t.channel != msg.channel {
+		return nil
+	}
+
+	older := []slack.Message{}
+	for _, message := range msg.messages {
+		// live updates might already have handed us some of these
+		if !slices.ContainsFunc(t.messages, func(existing slack.Message) bool { return existing.Timestamp == message.Timestamp }) {
+			older = append(older, message)
+		}
+	}
+	slices.Reverse(older)
+
+	t.historyLoading = false
+	t.historyCursor = msg.nextCursor
+	t.historyComplete = !msg.hasMore
+
+	// remember where the first message we already had sits so the view doesn't jump
+	anchor := -1
+	if len(t.offsets) > 0 {
+		anchor = t.offsets[0] - t.messagePager.YOffset
+	}
+
+	t.messages = append(older, t.messages...)
+	t.cursor += len(older)
+	m.refreshMessagePager(msg.tab)
+
+	if anchor != -1 && len(older) < len(t.offsets) {
+		t.messagePager.SetYOffset(t.offsets[len(older)] - anchor)
+	}
+
+	return nil
+}
+
+// how many messages before the one we're jumping to get loaded with it
+const contextMessages = 50
+
+// how many pages of newer messages we'll fetch to join the jump up with the present,
+// anything further back than that just loads without them
+const maxNewerPages = 5
+
+// loads the conversation around a message instead of from the newest end, so jumping to
+// something old doesn't mean paging back to it. older history pages on from there as usual
+func getMessagesAround(slackClient *slack.Client, channel string, timestamp string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		older, err := slackClient.GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: channel, Latest: timestamp, Inclusive: true, Limit: contextMessages})
+		if err != nil {
+			log.Error("error fetching messages", "err", err)
+
+			return errMsg{err}
+		}
+		update := tabMessageUpdate{messages: older.Messages, tab: tab, channel: channel, nextCursor: older.ResponseMetaData.NextCursor, hasMore: older.HasMore, partial: true}
+
+		// history after a point comes back from the newest end, so page down towards the
+		// message until there's nothing left in between
+		newer := []slack.Message{}
+		cursor := ""
+		for range maxNewerPages {
+			page, err := slackClient.GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: channel, Oldest: timestamp, Cursor: cursor, Limit: 200})
+			if err != nil {
+				log.Error("error fetching newer messages", "err", err)
+				return update
+			}
+			newer = append(newer, page.Messages...)
+
+			if !page.HasMore {
+				// both come back newest first
+				update.messages = append(newer, older.Messages...)
+				update.partial = false
+				return update
+			}
+			cursor = page.ResponseMetaData.NextCursor
+		}
+
+		return update
+	}
+}
@@ -0,0 +1,318 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// how many columns the info panel takes from the messages
+const infoPanelWidth = 40
+
+// how many members the panel lists per page
+const membersPerPage = 10
+
+var infoPanelStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#7D56F3")).
+	Padding(0, 1).Width(infoPanelWidth - 2)
+
+var infoHeadingStyle = highlightedStyle.
+	MarginTop(1)
+
+type channelInfo struct {
+	channel   slack.Channel
+	pins      []slack.Item
+	bookmarks []slack.Bookmark
+	members   []string
+	// where the next page of members starts, empty once we have them all
+	membersCursor string
+}
+
+type channelInfoUpdate struct {
+	tab  int
+	info channelInfo
+}
+
+type membersUpdate struct {
+	tab     int
+	channel string
+	members []string
+	cursor  string
+}
+
+func getChannelInfo(slackClient *slack.Client, channel string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		conversation, err := slackClient.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channel, IncludeNumMembers: true})
+		if err != nil {
+			log.Error("error fetching channel info", "channel", channel, "err", err)
+			return statusUpdate{tab, "couldn't load channel info: " + err.Error()}
+		}
+		info := channelInfo{channel: *conversation}
+		// looked up now so drawing the panel never has to ask slack
+		if conversation.Creator != "" {
+			database.GetUserOrCreate(conversation.Creator, *slackClient)
+		}
+
+		// pins and bookmarks are extras, the panel still works without them
+		if info.pins, _, err = slackClient.ListPins(channel); err != nil {
+			log.Error("error fetching pins", "channel", channel, "err", err)
+		}
+		if info.bookmarks, err = slackClient.ListBookmarks(channel); err != nil {
+			log.Error("error fetching bookmarks", "channel", channel, "err", err)
+		}
+
+		info.members, info.membersCursor, err = getMembers(slackClient, channel, "")
+		if err != nil {
+			log.Error("error fetching members", "channel", channel, "err", err)
+		}
+
+		return channelInfoUpdate{tab, info}
+	}
+}
+
+// fetches a page of members and looks up their names so drawing the panel doesn't have to
+func getMembers(slackClient *slack.Client, channel string, cursor string) ([]string, string, error) {
+	members, next, err := slackClient.GetUsersInConversation(&slack.GetUsersInConversationParameters{ChannelID: channel, Cursor: cursor, Limit: membersPerPage * 5})
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, member := range members {
+		database.GetUserOrCreate(member, *slackClient)
+	}
+
+	return members, next, nil
+}
+
+func getMoreMembers(slackClient *slack.Client, channel string, cursor string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		members, next, err := getMembers(slackClient, channel, cursor)
+		if err != nil {
+			log.Error("error fetching members", "channel", channel, "err", err)
+			return statusUpdate{tab, "couldn't load more members: " + err.Error()}
+		}
+
+		return membersUpdate{tab, channel, members, next}
+	}
+}
+
+func setChannelDetail(slackClient *slack.Client, channel string, field string, value string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		var conversation *slack.Channel
+		var err error
+		if field == "topic" {
+			conversation, err = slackClient.SetTopicOfConversation(channel, value)
+		} else {
+			conversation, err = slackClient.SetPurposeOfConversation(channel, value)
+		}
+		if err != nil {
+			log.Error("error setting channel "+field, "channel", channel, "err", err)
+			return statusUpdate{tab, "couldn't set the " + field + ": " + err.Error()}
+		}
+
+		return channelDetailUpdate{tab, *conversation}
+	}
+}
+
+type channelDetailUpdate struct {
+	tab     int
+	channel slack.Channel
+}
+
+// shows or hides the panel, the messages get re-rendered to fit whatever space is left
+func (m Model) toggleInfoPanel(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.showInfo = !t.showInfo
+	t.infoPage = 0
+
+	t.messagePager.Width = m.width - 4
+	if t.showInfo {
+		t.messagePager.Width -= infoPanelWidth
+	}
+	t.rendered = map[string]string{}
+	m.refreshMessagePager(tab)
+	m.followViewport(tab)
+
+	if !t.showInfo {
+		return nil
+	}
+	if t.info == nil || t.info.channel.ID != t.channel {
+		t.info = nil
+		return getChannelInfo(m.slackClient, t.channel, tab)
+	}
+	return nil
+}
+
+func (m Model) editChannelDetail(tab int, field string) tea.Cmd {
+	t := &m.tabs[tab]
+	if t.info == nil {
+		return nil
+	}
+
+	t.state = "editInfo"
+	t.infoField = field
+	t.infoInput.Placeholder = "the channel's " + field
+	if field == "topic" {
+		t.infoInput.SetValue(t.info.channel.Topic.Value)
+	} else {
+		t.infoInput.SetValue(t.info.channel.Purpose.Value)
+	}
+
+	return t.infoInput.Focus()
+}
+
+func (m Model) saveChannelDetail(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.status = "saving the " + t.infoField + "..."
+	return setChannelDetail(m.slackClient, t.channel, t.infoField, t.infoInput.Value(), tab)
+}
+
+// the panel's own keys, only while the pager has focus so they don't get in the way of typing
+func (m Model) updateInfoPanel(tab int, msg tea.KeyMsg) (bool, tea.Cmd) {
+	t := &m.tabs[tab]
+	if !t.showInfo || t.state != "messages" || t.focused != 0 || t.info == nil {
+		return false, nil
+	}
+
+	switch msg.String() {
+	case "t":
+		return true, m.editChannelDetail(tab, "topic")
+	case "p":
+		return true, m.editChannelDetail(tab, "purpose")
+	case "[":
+		t.infoPage = max(t.infoPage-1, 0)
+		return true, nil
+	case "]":
+		if (t.infoPage+1)*membersPerPage < len(t.info.members) {
+			t.infoPage++
+			return true, nil
+		}
+		// ran out of what we've loaded, fetch the next batch if there is one
+		if t.info.membersCursor != "" {
+			t.infoPage++
+			return true, getMoreMembers(m.slackClient, t.channel, t.info.membersCursor, tab)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (m Model) updateInfoInput(tab int, msg tea.Msg) tea.Cmd {
+	t := &m.tabs[tab]
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && key.Matches(keyMsg, m.keys.Enter) {
+		return nil
+	}
+
+	var cmd tea.Cmd
+	t.infoInput, cmd = t.infoInput.Update(msg)
+	return cmd
+}
+
+// only reads the cache so it's safe to call while drawing
+func userName(userID string) string {
+	user := database.QuerySlackUserID(userID)
+	switch {
+	case user.DisplayName == "" && user.RealName == "":
+		return mutedStyle.Render("@" + userID)
+	case user.DisplayName == "":
+		return highlightedStyleBot.Render("@" + user.RealName + " (bot)")
+	}
+	return highlightedStyle.Render("@" + user.DisplayName)
+}
+
+func infoPanelView(m Model, height int) string {
+	t := m.tabs[m.activeTab]
+	width := infoPanelWidth - 4
+
+	if t.info == nil {
+		return infoPanelStyle.Height(height - 2).Render(mutedStyle.Render("loading channel info..."))
+	}
+	channel := t.info.channel
+
+	clamp := func(s string) string {
+		return utils.ClampString(strings.ReplaceAll(s, "\n", " "), width*3)
+	}
+	orNothing := func(s string) string {
+		if s == "" {
+			return mutedStyle.Render("nothing yet")
+		}
+		return evenLessMutedStyle.Render(clamp(s))
+	}
+
+	name := "#" + channel.Name
+	if channel.IsIM || channel.IsMpIM {
+		name = "direct message"
+	}
+
+	var b strings.Builder
+	b.WriteString(highlightedStyle.Render(name) + "\n")
+	b.WriteString(lessMutedStyle.Render("topic ") + mutedStyle.Render("(t to edit)") + "\n" + orNothing(channel.Topic.Value) + "\n")
+	b.WriteString(lessMutedStyle.Render("purpose ") + mutedStyle.Render("(p to edit)") + "\n" + orNothing(channel.Purpose.Value) + "\n")
+	if channel.Creator != "" {
+		b.WriteString(lessMutedStyle.Render("created by ") + userName(channel.Creator) + "\n")
+	}
+	b.WriteString(lessMutedStyle.Render("on ") + evenLessMutedStyle.Render(channel.Created.Time().Format(time.DateOnly)) + "\n")
+
+	b.WriteString(infoHeadingStyle.Render(fmt.Sprintf("members (%d)", channel.NumMembers)) + "\n")
+	start := t.infoPage * membersPerPage
+	if start >= len(t.info.members) && t.info.membersCursor != "" {
+		b.WriteString(mutedStyle.Render("loading...") + "\n")
+	}
+	for _, member := range t.info.members[min(start, len(t.info.members)):min(start+membersPerPage, len(t.info.members))] {
+		b.WriteString(userName(member) + "\n")
+	}
+	pages := max((channel.NumMembers+membersPerPage-1)/membersPerPage, 1)
+	b.WriteString(mutedStyle.Render(fmt.Sprintf("page %d of %d ([ and ] to flip)", t.infoPage+1, pages)) + "\n")
+
+	b.WriteString(infoHeadingStyle.Render(fmt.Sprintf("pinned (%d)", len(t.info.pins))) + "\n")
+	for _, pin := range t.info.pins {
+		switch {
+		case pin.Message != nil:
+			b.WriteString(evenLessMutedStyle.Render(utils.ClampString("• "+strings.ReplaceAll(pin.Message.Text, "\n", " "), width)) + "\n")
+		case pin.File != nil:
+			b.WriteString(evenLessMutedStyle.Render(utils.ClampString("• "+pin.File.Name, width)) + "\n")
+		}
+	}
+
+	b.WriteString(infoHeadingStyle.Render(fmt.Sprintf("bookmarks (%d)", len(t.info.bookmarks))) + "\n")
+	for _, bookmark := range t.info.bookmarks {
+		b.WriteString(evenLessMutedStyle.Render(utils.ClampString("• "+bookmark.Title, width)) + "\n")
+		if bookmark.Link != "" {
+			b.WriteString(mutedStyle.Render(utils.ClampString("  "+bookmark.Link, width)) + "\n")
+		}
+	}
+
+	// anything that doesn't fit is cut off at the bottom rather than pushing the composer down
+	content := strings.Split(lipgloss.NewStyle().Width(width).Render(strings.TrimSuffix(b.String(), "\n")), "\n")
+	if len(content) > height-2 {
+		content = content[:height-2]
+	}
+
+	return infoPanelStyle.Height(height - 2).Render(strings.Join(content, "\n"))
+}
+
+// the conversation with the panel down its right hand side
+func withInfoPanel(m Model, view string) string {
+	t := m.tabs[m.activeTab]
+	if !t.showInfo {
+		return view
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, view, infoPanelView(m, lipgloss.Height(view)))
+}
+
+func editInfoView(m Model) string {
+	t := m.tabs[m.activeTab]
+	return withInfoPanel(m, t.messagePager.View()) + "\n" + statusView(m) +
+		lessMutedStyle.Render("editing the channel "+t.infoField+" (enter to save, ctrl+b to cancel)") + "\n" + t.infoInput.View()
+}
@@ -0,0 +1,202 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/events"
+	"charming-slack/libs/utils"
+)
+
+// searches the messages we've already fetched, no network needed
+const localMode = 2
+
+// the id out of an encoded <#C123> or <@U123>
+var mentionIDRe = regexp.MustCompile(`^<[#@]([A-Z0-9]+)`)
+
+// keeps the offline index in step with whatever a live event did
+func (m Model) indexEvent(event events.Event) {
+	switch event.Kind {
+	case events.MessageNew, events.MessageChanged, events.ThreadReply:
+		database.IndexMessages(m.user, event.Channel, []slack.Message{event.Message})
+	case events.MessageDeleted:
+		database.UnindexMessage(m.user, event.Channel, event.Message.Timestamp)
+	}
+}
+
+// the form as a query for the local index, names have to resolve to ids since there's no slack to ask
+func (m Model) localSearchQuery() (database.IndexQuery, error) {
+	t := m.tabs[searchTab]
+	form := t.searchForm
+	channels := append(slices.Clone(m.channels), m.privateChannels...)
+
+	query := database.IndexQuery{
+		Text:    m.searchInput.Value(),
+		HasLink: searchHas[form.has] == "link",
+		HasFile: searchHas[form.has] == "file",
+		ByTime:  searchSorts[form.sort].sort == "timestamp",
+	}
+
+	resolve := func(name string) string {
+		encoded, _ := utils.EncodeMentions(name, m.directory, channels)
+		if match := mentionIDRe.FindStringSubmatch(encoded); match != nil {
+			return match[1]
+		}
+		return ""
+	}
+
+	if in := strings.TrimSpace(form.in.Value()); in != "" {
+		if strings.HasPrefix(in, "@") {
+			// a person means the dm with them
+			user := resolve(in)
+			if i := slices.IndexFunc(m.dms, func(dm slack.Channel) bool { return dm.IsIM && dm.User == user }); user != "" && i != -1 {
+				query.Channel = m.dms[i].ID
+			}
+		} else {
+			query.Channel = resolve("#" + strings.TrimPrefix(in, "#"))
+		}
+		if query.Channel == "" {
+			return query, fmt.Errorf("couldn't find a conversation called %s", in)
+		}
+	}
+	if from := strings.TrimSpace(form.from.Value()); from != "" {
+		query.User = resolve("@" + strings.TrimPrefix(from, "@"))
+		if query.User == "" {
+			return query, fmt.Errorf("couldn't find anyone called %s", from)
+		}
+	}
+
+	for _, date := range []struct {
+		bound *time.Time
+		input string
+	}{{&query.After, form.after.Value()}, {&query.Before, form.before.Value()}} {
+		if value := strings.TrimSpace(date.input); value != "" {
+			parsed, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+			if err != nil {
+				return query, err
+			}
+			*date.bound = parsed
+		}
+	}
+	// slack's after is the day after, not the start of it
+	if !query.After.IsZero() {
+		query.After = query.After.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	return query, nil
+}
+
+// looks through the index straight away and hands the page back like slack would
+func (m Model) searchLocal(page int) tea.Cmd {
+	query, err := m.localSearchQuery()
+	if err != nil {
+		return func() tea.Msg {
+			return statusUpdate{searchTab, err.Error()}
+		}
+	}
+
+	results := database.SearchIndex(m.user, query)
+	pages := (len(results) + searchPageSize - 1) / searchPageSize
+	page = min(max(page, 1), max(pages, 1))
+	start := (page - 1) * searchPageSize
+
+	messages := []slack.SearchMessage{}
+	for _, result := range results[start:min(start+searchPageSize, len(results))] {
+		message := result.Message
+
+		// only the thread matters for jumping to it, there's no workspace url to make a real link with
+		permalink := ""
+		if message.ThreadTimestamp != "" {
+			permalink = "?thread_ts=" + message.ThreadTimestamp
+		}
+
+		messages = append(messages, slack.SearchMessage{
+			Channel:   slack.CtxChannel{ID: message.Channel, Name: strings.TrimPrefix(m.conversationName(message.Channel), "#")},
+			User:      message.User,
+			Timestamp: message.Timestamp,
+			Text:      highlightMatches(message.Text, result.Matches),
+			Permalink: permalink,
+		})
+	}
+
+	update := searchMessageUpdate{messages: messages, page: page, pages: pages, total: len(results)}
+	slackClient := *m.slackClient
+	return func() tea.Msg {
+		// almost always cached already, but anyone who isn't gets looked up here rather than while drawing
+		for _, message := range messages {
+			utils.CacheUsers(slackClient, message.User, message.Text)
+		}
+		return update
+	}
+}
+
+// wraps the words that matched in the same markers slack uses, so both kinds of result look alike.
+// mentions and links are left alone since they get rewritten after
+func highlightMatches(text string, matches []string) string {
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if slices.Contains(matches, strings.ToLower(string(word))) {
+			b.WriteString(highlightStart + string(word) + highlightEnd)
+		} else {
+			b.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+
+	inside := false
+	for _, r := range text {
+		switch {
+		case r == '<':
+			flush()
+			inside = true
+			b.WriteRune(r)
+		case r == '>' && inside:
+			inside = false
+			b.WriteRune(r)
+		case !inside && (unicode.IsLetter(r) || unicode.IsNumber(r)):
+			word = append(word, r)
+		default:
+			flush()
+			b.WriteRune(r)
+		}
+	}
+	flush()
+
+	return b.String()
+}
+
+// how many fetched messages the form matches, shown while it's being filled in. it's only
+// counted again once the query changes so redrawing the form doesn't search the index
+func (m Model) refreshLocalMatchCount() {
+	t := &m.tabs[searchTab]
+	compiled, err := m.compileSearch()
+	if t.searchForm.mode != localMode || err != nil {
+		t.localMatches = ""
+		t.localMatchQuery = ""
+		return
+	}
+	if compiled == t.localMatchQuery && t.localMatches != "" {
+		return
+	}
+	t.localMatchQuery = compiled
+
+	query, err := m.localSearchQuery()
+	if err != nil {
+		t.localMatches = mutedStyle.Render(err.Error()) + "\n"
+		return
+	}
+
+	t.localMatches = mutedStyle.Render(fmt.Sprintf("%d fetched messages match", len(database.SearchIndex(m.user, query)))) + "\n"
+}
@@ -0,0 +1,400 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/events"
+)
+
+type statusUpdate struct {
+	tab  int
+	text string
+}
+
+func setStatus(tab int, text string) tea.Cmd {
+	return func() tea.Msg {
+		return statusUpdate{tab, text}
+	}
+}
+
+type messageAction struct {
+	title string
+	run   func(m Model, tab int, message slack.Message) tea.Cmd
+	// nil means the action works on any message
+	available func(m Model, message slack.Message) bool
+}
+
+// everything you can do to the selected message, in the order the menu shows them
+var messageActions = []messageAction{
+	{"reply in thread", replyInThreadAction, nil},
+	{"react", reactAction, nil},
+	{"copy text", copyTextAction, nil},
+	{"copy permalink", copyPermalinkAction, nil},
+	{"quote into composer", quoteAction, nil},
+	{"view sender's profile", profileAction, hasSender},
+	{"pin to channel", flagAction("pinned", true), notPinned},
+	{"unpin from channel", flagAction("pinned", false), pinned},
+	{"save for later", flagAction("saved", true), notSaved},
+	{"remove from saved", flagAction("saved", false), saved},
+	{"edit", editAction, ownMessage},
+	{"delete", deleteAction, ownMessage},
+}
+
+func ownMessage(m Model, message slack.Message) bool {
+	return m.userID != "" && message.User == m.userID
+}
+
+func (m Model) availableActions(message slack.Message) []messageAction {
+	actions := []messageAction{}
+	for _, action := range messageActions {
+		if action.available == nil || action.available(m, message) {
+			actions = append(actions, action)
+		}
+	}
+
+	return actions
+}
+
+func (t tab) selectedMessage() (slack.Message, bool) {
+	if t.cursor < 0 || t.cursor >= len(t.messages) {
+		return slack.Message{}, false
+	}
+
+	return t.messages[t.cursor], true
+}
+
+func (m Model) moveCursor(tab int, delta int) {
+	t := &m.tabs[tab]
+	if len(t.messages) == 0 {
+		return
+	}
+
+	t.cursor = min(max(t.cursor+delta, 0), len(t.messages)-1)
+	m.refreshMessagePager(tab)
+
+	// scroll just far enough to get the whole selected message on screen
+	top := t.offsets[t.cursor]
+	bottom := top + lipgloss.Height(t.rendered[t.messages[t.cursor].Timestamp]) + 2
+	if bottom > t.messagePager.YOffset+t.messagePager.Height {
+		t.messagePager.SetYOffset(bottom - t.messagePager.Height)
+	}
+	if top < t.messagePager.YOffset {
+		t.messagePager.SetYOffset(top)
+	}
+}
+
+// after paging the viewport pull the cursor along if it scrolled out of sight
+func (m Model) followViewport(tab int) {
+	t := &m.tabs[tab]
+	if len(t.offsets) != len(t.messages) || len(t.messages) == 0 {
+		return
+	}
+
+	top := t.messagePager.YOffset
+	bottom := top + t.messagePager.Height
+	if offset := t.offsets[t.cursor]; offset >= top && offset < bottom {
+		return
+	}
+
+	visible := 0
+	for i, offset := range t.offsets {
+		if offset > top {
+			break
+		}
+		visible = i
+	}
+
+	if visible != t.cursor {
+		t.cursor = visible
+		offset := t.messagePager.YOffset
+		m.refreshMessagePager(tab)
+		t.messagePager.SetYOffset(offset)
+	}
+}
+
+func (m Model) openActions(tab int) {
+	t := &m.tabs[tab]
+	message, ok := t.selectedMessage()
+	if !ok {
+		return
+	}
+
+	t.state = "actions"
+	t.actionCursor = 0
+	t.actionTimestamp = message.Timestamp
+}
+
+// the message the action menu was opened on, even if the cursor has moved since
+func (t tab) actionTarget() (slack.Message, bool) {
+	index := slices.IndexFunc(t.messages, func(message slack.Message) bool {
+		return message.Timestamp == t.actionTimestamp
+	})
+	if t.actionTimestamp == "" || index == -1 {
+		return slack.Message{}, false
+	}
+
+	return t.messages[index], true
+}
+
+func (m Model) runSelectedAction(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+
+	message, ok := t.actionTarget()
+	if !ok {
+		t.state = "messages"
+		return nil
+	}
+
+	actions := m.availableActions(message)
+	if t.actionCursor >= len(actions) {
+		t.state = "messages"
+		return nil
+	}
+
+	action := actions[t.actionCursor]
+	t.state = "messages"
+	t.status = ""
+
+	return action.run(m, tab, message)
+}
+
+func replyInThreadAction(m Model, tab int, message slack.Message) tea.Cmd {
+	return m.openThread(tab, message)
+}
+
+func copyTextAction(m Model, tab int, message slack.Message) tea.Cmd {
+	output := m.output
+	return func() tea.Msg {
+		output.Copy(message.Text)
+		return statusUpdate{tab, "copied message text"}
+	}
+}
+
+func copyPermalinkAction(m Model, tab int, message slack.Message) tea.Cmd {
+	slackClient := *m.slackClient
+	output := m.output
+	channel := m.tabs[tab].channel
+	return func() tea.Msg {
+		permalink, err := slackClient.GetPermalink(&slack.PermalinkParameters{Channel: channel, Ts: message.Timestamp})
+		if err != nil {
+			log.Error("error getting permalink", "err", err)
+			return statusUpdate{tab, "couldn't get permalink: " + err.Error()}
+		}
+
+		output.Copy(permalink)
+		return statusUpdate{tab, "copied " + permalink}
+	}
+}
+
+func quoteAction(m Model, tab int, message slack.Message) tea.Cmd {
+	t := &m.tabs[tab]
+
+	var quoted strings.Builder
+	for _, line := range strings.Split(message.Text, "\n") {
+		quoted.WriteString("> " + line + "\n")
+	}
+
+	t.messageInput.SetValue(quoted.String())
+	m.refreshCompletions(tab)
+	t.focused = 1
+
+	return t.messageInput.Focus()
+}
+
+func editAction(m Model, tab int, message slack.Message) tea.Cmd {
+	t := &m.tabs[tab]
+	t.editTimestamp = message.Timestamp
+	t.messageInput.SetValue(message.Text)
+	m.refreshCompletions(tab)
+	t.focused = 1
+
+	return t.messageInput.Focus()
+}
+
+// deleting can't be undone so it goes through a confirmation screen first
+func deleteAction(m Model, tab int, _ slack.Message) tea.Cmd {
+	m.tabs[tab].state = "confirmDelete"
+	return nil
+}
+
+func (m Model) deleteConfirmedMessage(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.state = "messages"
+
+	message, ok := t.actionTarget()
+	t.actionTimestamp = ""
+	if !ok {
+		return setStatus(tab, "the message you were deleting is gone")
+	}
+	if !ownMessage(m, message) {
+		return nil
+	}
+
+	slackClient := *m.slackClient
+	channel := t.channel
+	return func() tea.Msg {
+		_, _, err := slackClient.DeleteMessage(channel, message.Timestamp)
+		if err != nil {
+			log.Error("error deleting message", "err", err)
+			return statusUpdate{tab, "couldn't delete message: " + err.Error()}
+		}
+
+		return localEventUpdate{events.Event{Kind: events.MessageDeleted, Channel: channel, Message: message}}
+	}
+}
+
+type editMessageUpdate struct{ event events.Event }
+
+func (m Model) editMessage(tab int, text string) tea.Cmd {
+	t := m.tabs[tab]
+
+	index := slices.IndexFunc(t.messages, func(message slack.Message) bool {
+		return message.Timestamp == t.editTimestamp
+	})
+	if index == -1 {
+		return setStatus(tab, "the message you were editing is gone")
+	}
+	message := t.messages[index]
+
+	slackClient := *m.slackClient
+	channel := t.channel
+	userID := m.userID
+	return func() tea.Msg {
+		_, _, newText, err := slackClient.UpdateMessage(channel, message.Timestamp, slack.MsgOptionText(text, false))
+		if err != nil {
+			log.Error("error editing message", "err", err)
+			return errMsg{err}
+		}
+
+		// show the edit now, the stream will hand us slack's copy in a bit
+		message.Text = newText
+		message.Edited = &slack.Edited{User: userID, Timestamp: fmt.Sprintf("%d.000000", time.Now().Unix())}
+		return editMessageUpdate{events.Event{Kind: events.MessageChanged, Channel: channel, Message: message}}
+	}
+}
+
+// handles keys meant for the message list and menus before the bubbles see them
+func (m Model) updateMessageSelection(tab int, msg tea.KeyMsg) (bool, tea.Cmd) {
+	t := &m.tabs[tab]
+
+	switch t.state {
+	case "messages":
+		if t.focused != 0 {
+			return false, nil
+		}
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			m.moveCursor(tab, -1)
+			return true, nil
+		case key.Matches(msg, m.keys.Down):
+			m.moveCursor(tab, 1)
+			return true, nil
+		}
+	case "actions":
+		message, ok := t.actionTarget()
+		if !ok {
+			return true, nil
+		}
+		count := len(m.availableActions(message))
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			t.actionCursor = (t.actionCursor - 1 + count) % count
+		case key.Matches(msg, m.keys.Down):
+			t.actionCursor = (t.actionCursor + 1) % count
+		}
+		return true, nil
+	case "confirmDelete":
+		return true, nil
+	case "browse", "pickUsers":
+		count := len(t.browseResults())
+		if t.state == "pickUsers" {
+			count = len(m.directoryResults(m.activeTab))
+		}
+		switch msg.String() {
+		case "up":
+			t.browseCursor = max(t.browseCursor-1, 0)
+			return true, nil
+		case "down":
+			t.browseCursor = max(min(t.browseCursor+1, count-1), 0)
+			return true, nil
+		}
+	case "react":
+		// only the arrow keys, j and k are letters you might be typing
+		count := len(emojiSuggestions(t.reactionInput.Value()))
+		switch msg.String() {
+		case "up":
+			if count > 0 {
+				t.reactionCursor = (t.reactionCursor - 1 + count) % count
+			}
+			return true, nil
+		case "down":
+			if count > 0 {
+				t.reactionCursor = (t.reactionCursor + 1) % count
+			}
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func actionsView(m Model) string {
+	t := m.tabs[m.activeTab]
+
+	message, ok := t.actionTarget()
+	if !ok {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, action := range m.availableActions(message) {
+		if i == t.actionCursor {
+			b.WriteString(selectedItemStyle.Render("> "+action.title) + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(action.title) + "\n")
+		}
+	}
+
+	body, ok := t.rendered[message.Timestamp]
+	if !ok {
+		body = m.renderMessageBody(message)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		selectedMessageStyle.Width(m.width-12).Render(body),
+		"",
+		lessMutedStyle.Render("what do you want to do with this message?"),
+		"",
+		b.String(),
+	)
+}
+
+func confirmDeleteView(m Model) string {
+	t := m.tabs[m.activeTab]
+
+	message, ok := t.actionTarget()
+	if !ok {
+		return ""
+	}
+
+	body, ok := t.rendered[message.Timestamp]
+	if !ok {
+		body = m.renderMessageBody(message)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		selectedMessageStyle.Width(m.width-12).Render(body),
+		"",
+		highlightedStyleBot.Render("delete this message? this can't be undone"),
+		lessMutedStyle.Render("enter to delete it, ctrl+b to keep it"),
+	)
+}
@@ -0,0 +1,349 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/utils"
+)
+
+// the named colors legacy attachments can use instead of hex
+var attachmentColors = map[string]string{
+	"good":    "#2eb886",
+	"warning": "#daa038",
+	"danger":  "#a30200",
+}
+
+// runs text through the same glamour pipeline as the message body
+func (m Model) renderMarkdown(text string, width int) string {
+	glamR, _ := glamour.NewTermRenderer(
+		glamour.WithWordWrap(width),
+		glamour.WithStylePath("styles/overgrown.json"),
+		glamour.WithPreservedNewLines(),
+	)
+
+	glamString, _ := glamR.Render(utils.UrlParser(text))
+
+	return glamString
+}
+
+// everything inside the box below the header: text or blocks, attachments and files
+func (m Model) renderMessageContent(message slack.Message) string {
+	var b strings.Builder
+
+	text := message.Text
+	if blocksReplaceText(message) {
+		text = m.blocksToMarkdown(message.Blocks.BlockSet)
+	}
+	if text != "" {
+		b.WriteString(m.renderMarkdown(text, m.width-18))
+	}
+
+	for _, attachment := range message.Attachments {
+		b.WriteString(m.renderAttachment(attachment))
+	}
+
+	for _, file := range message.Files {
+		b.WriteString(renderFile(file))
+	}
+
+	return b.String()
+}
+
+// slack sends a plain text fallback next to the blocks for notifications, when the
+// blocks are just a rich_text copy of what someone typed the text reads the same
+func blocksReplaceText(message slack.Message) bool {
+	if len(message.Blocks.BlockSet) == 0 {
+		return false
+	}
+	if message.Text == "" {
+		return true
+	}
+
+	for _, block := range message.Blocks.BlockSet {
+		if block.BlockType() != slack.MBTRichText {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m Model) blocksToMarkdown(blocks []slack.Block) string {
+	parts := []string{}
+	for _, block := range blocks {
+		switch block := block.(type) {
+		case *slack.HeaderBlock:
+			if block.Text != nil {
+				parts = append(parts, "### "+block.Text.Text)
+			}
+		case *slack.DividerBlock:
+			parts = append(parts, "---")
+		case *slack.SectionBlock:
+			if block.Text != nil {
+				parts = append(parts, block.Text.Text)
+			}
+			for _, field := range block.Fields {
+				parts = append(parts, field.Text)
+			}
+			if block.Accessory != nil && block.Accessory.ImageElement != nil {
+				parts = append(parts, imageAlt(block.Accessory.ImageElement.AltText))
+			}
+		case *slack.ContextBlock:
+			elements := []string{}
+			for _, element := range block.ContextElements.Elements {
+				switch element := element.(type) {
+				case *slack.TextBlockObject:
+					elements = append(elements, element.Text)
+				case *slack.ImageBlockElement:
+					elements = append(elements, imageAlt(element.AltText))
+				}
+			}
+			if len(elements) > 0 {
+				parts = append(parts, "_"+strings.Join(elements, "  ·  ")+"_")
+			}
+		case *slack.ImageBlock:
+			alt := block.AltText
+			if block.Title != nil && block.Title.Text != "" {
+				alt = block.Title.Text
+			}
+			parts = append(parts, imageAlt(alt))
+		case *slack.RichTextBlock:
+			for _, element := range block.Elements {
+				parts = append(parts, m.richTextToMarkdown(element))
+			}
+		}
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+func imageAlt(alt string) string {
+	if alt == "" {
+		return "_[image]_"
+	}
+	return "_[image: " + alt + "]_"
+}
+
+func (m Model) richTextToMarkdown(element slack.RichTextElement) string {
+	switch element := element.(type) {
+	case *slack.RichTextSection:
+		return m.richTextSectionToMarkdown(element.Elements)
+	case *slack.RichTextQuote:
+		lines := strings.Split(m.richTextSectionToMarkdown(element.Elements), "\n")
+		return "> " + strings.Join(lines, "\n> ")
+	case *slack.RichTextPreformatted:
+		var b strings.Builder
+		// code blocks keep their text as is, styling would only add stray markers
+		for _, inner := range element.Elements {
+			switch inner := inner.(type) {
+			case *slack.RichTextSectionTextElement:
+				b.WriteString(inner.Text)
+			case *slack.RichTextSectionLinkElement:
+				b.WriteString(inner.URL)
+			}
+		}
+		return "```\n" + strings.TrimSuffix(b.String(), "\n") + "\n```"
+	case *slack.RichTextList:
+		items := []string{}
+		for i, inner := range element.Elements {
+			bullet := "- "
+			if element.Style == slack.RTEListOrdered {
+				bullet = fmt.Sprintf("%d. ", i+1)
+			}
+			items = append(items, strings.Repeat("  ", element.Indent)+bullet+m.richTextToMarkdown(inner))
+		}
+		return strings.Join(items, "\n")
+	}
+
+	return ""
+}
+
+func (m Model) richTextSectionToMarkdown(elements []slack.RichTextSectionElement) string {
+	var b strings.Builder
+	for _, element := range elements {
+		switch element := element.(type) {
+		case *slack.RichTextSectionTextElement:
+			b.WriteString(styleRichText(element.Text, element.Style))
+		case *slack.RichTextSectionLinkElement:
+			label := element.Text
+			if label == "" {
+				label = element.URL
+			}
+			b.WriteString("[" + styleRichText(label, element.Style) + "](" + element.URL + ")")
+		case *slack.RichTextSectionUserElement:
+			// left as a mention so UserIdParser picks it up like in plain text
+			b.WriteString("<@" + element.UserID + ">")
+		case *slack.RichTextSectionChannelElement:
+			b.WriteString("#" + m.channelName(element.ChannelID))
+		case *slack.RichTextSectionEmojiElement:
+			b.WriteString(":" + element.Name + ":")
+		case *slack.RichTextSectionBroadcastElement:
+			b.WriteString("@" + element.Range)
+		case *slack.RichTextSectionUserGroupElement:
+			b.WriteString("@" + element.UsergroupID)
+		case *slack.RichTextSectionTeamElement:
+			b.WriteString(element.TeamID)
+		case *slack.RichTextSectionDateElement:
+			b.WriteString(element.Timestamp.Time().Format(time.DateTime))
+		case *slack.RichTextSectionColorElement:
+			b.WriteString(element.Value)
+		}
+	}
+
+	return b.String()
+}
+
+// wraps text in markdown markers, keeping surrounding whitespace outside them
+// since markdown ignores markers that hug a space
+func styleRichText(text string, style *slack.RichTextSectionTextStyle) string {
+	if style == nil || strings.TrimSpace(text) == "" {
+		return text
+	}
+
+	trimmed := strings.TrimSpace(text)
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+
+	if style.Code {
+		trimmed = "`" + trimmed + "`"
+	}
+	if style.Bold {
+		trimmed = "**" + trimmed + "**"
+	}
+	if style.Italic {
+		trimmed = "_" + trimmed + "_"
+	}
+	if style.Strike {
+		trimmed = "~~" + trimmed + "~~"
+	}
+
+	return leading + trimmed + trailing
+}
+
+// the name of a channel we already know about, falling back to its id
+func (m Model) channelName(id string) string {
+	for _, channels := range [][]slack.Channel{m.channels, m.privateChannels} {
+		for _, channel := range channels {
+			if channel.ID == id {
+				return channel.Name
+			}
+		}
+	}
+
+	return id
+}
+
+// legacy attachments get a colored bar down the left like they do in slack
+func (m Model) renderAttachment(attachment slack.Attachment) string {
+	parts := []string{}
+	if attachment.AuthorName != "" {
+		parts = append(parts, "**"+attachment.AuthorName+"**")
+	}
+	switch {
+	case attachment.Title != "" && attachment.TitleLink != "":
+		parts = append(parts, "**["+attachment.Title+"]("+attachment.TitleLink+")**")
+	case attachment.Title != "":
+		parts = append(parts, "**"+attachment.Title+"**")
+	}
+	if attachment.Text != "" {
+		parts = append(parts, attachment.Text)
+	}
+	for _, field := range attachment.Fields {
+		parts = append(parts, "**"+field.Title+"**\n"+field.Value)
+	}
+	if len(attachment.Blocks.BlockSet) > 0 {
+		parts = append(parts, m.blocksToMarkdown(attachment.Blocks.BlockSet))
+	}
+	if attachment.ImageURL != "" {
+		parts = append(parts, imageAlt(""))
+	}
+	if attachment.Footer != "" {
+		parts = append(parts, "_"+attachment.Footer+"_")
+	}
+	if len(parts) == 0 && attachment.Fallback != "" {
+		parts = append(parts, attachment.Fallback)
+	}
+
+	var b strings.Builder
+	if attachment.Pretext != "" {
+		b.WriteString(m.renderMarkdown(attachment.Pretext, m.width-18))
+	}
+	if len(parts) == 0 {
+		return b.String()
+	}
+
+	color := attachment.Color
+	if named, ok := attachmentColors[color]; ok {
+		color = named
+	} else if color != "" && !strings.HasPrefix(color, "#") {
+		color = "#" + color
+	}
+	if color == "" {
+		color = "#92909b"
+	}
+
+	body := strings.Trim(m.renderMarkdown(strings.Join(parts, "\n\n"), m.width-21), "\n")
+	body = lipgloss.NewStyle().
+		Border(lipgloss.ThickBorder(), false, false, false, true).
+		BorderForeground(lipgloss.Color(color)).
+		Render(body)
+
+	b.WriteString(lipgloss.NewStyle().MarginLeft(2).Render(body) + "\n\n")
+
+	return b.String()
+}
+
+func renderFile(file slack.File) string {
+	name := file.Name
+	if file.Title != "" && file.Title != file.Name {
+		name = file.Title + " (" + file.Name + ")"
+	}
+
+	kind := file.PrettyType
+	if kind == "" {
+		kind = file.Filetype
+	}
+
+	details := []string{}
+	if kind != "" {
+		details = append(details, kind)
+	}
+	if file.Size > 0 {
+		details = append(details, humanSize(file.Size))
+	}
+
+	fileString := lessMutedStyle.Render("  file: ") + evenLessMutedStyle.Render(name)
+	if len(details) > 0 {
+		fileString += mutedStyle.Render(" - " + strings.Join(details, ", "))
+	}
+	if file.Permalink != "" {
+		fileString += "\n  " + mutedStyle.Render(file.Permalink)
+	}
+
+	return fileString + "\n"
+}
+
+func humanSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	suffix := ""
+	for _, suffix = range suffixes {
+		value /= unit
+		if value < unit {
+			break
+		}
+	}
+
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
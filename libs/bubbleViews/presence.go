@@ -0,0 +1,419 @@
+package bubbleViews
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// presence changes more often than unreads but each check is a call per person
+const presenceRefreshInterval = time.Minute
+
+// presence is a call per person so only whoever is on screen gets checked, and never more than this
+const maxPresenceUsers = 20
+
+var activeDotStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#2BAC76"))
+
+type presenceUpdate struct {
+	// whether anyone's presence or status is different from what we showed
+	changed   bool
+	scheduled bool
+}
+
+type presenceTick struct{}
+
+func schedulePresenceRefresh() tea.Cmd {
+	return tea.Tick(presenceRefreshInterval, func(time.Time) tea.Msg {
+		return presenceTick{}
+	})
+}
+
+// the people whose presence is on screen right now, the visible page of the dm list or
+// whoever sent the messages in view in the open conversation
+func (m Model) presenceUsers() []string {
+	users := []string{}
+	add := func(user string) {
+		if user != "" && !slices.Contains(users, user) {
+			users = append(users, user)
+		}
+	}
+
+	t := m.tabs[m.activeTab]
+	switch {
+	case m.activeTab == 2 && t.state == "select":
+		start, end := m.dmList.Paginator.GetSliceBounds(len(m.dms))
+		for _, dm := range m.dms[start:end] {
+			if dm.IsIM {
+				add(dm.User)
+			}
+		}
+	case t.channel != "":
+		top, bottom := t.messagePager.YOffset, t.messagePager.YOffset+t.messagePager.Height
+		for i, message := range t.messages {
+			if i < len(t.offsets) && t.offsets[i] < bottom && (i+1 >= len(t.offsets) || t.offsets[i+1] > top) {
+				add(message.User)
+			}
+		}
+		if t.state == "thread" {
+			for _, message := range t.threadMessages {
+				add(message.User)
+			}
+		}
+	}
+
+	return users[:min(len(users), maxPresenceUsers)]
+}
+
+func getPresence(slackClient *slack.Client, users []string, scheduled bool) tea.Cmd {
+	return func() tea.Msg {
+		changed := false
+
+		// only people already cached can show a presence, anyone else isn't worth a lookup
+		users = slices.DeleteFunc(slices.Clone(users), func(user string) bool {
+			_, ok := database.CachedSlackUser(user)
+			return !ok
+		})
+
+		// statuses come back in bulk, presence has to be asked for one person at a time
+		for start := 0; start < len(users); start += 30 {
+			infos, err := slackClient.GetUsersInfo(users[start:min(start+30, len(users))]...)
+			if err != nil {
+				log.Error("error fetching statuses", "err", err)
+				break
+			}
+			for _, info := range *infos {
+				cached, ok := database.CachedSlackUser(info.ID)
+				if !ok {
+					continue
+				}
+				if cached.StatusText != info.Profile.StatusText || cached.StatusEmoji != info.Profile.StatusEmoji {
+					changed = true
+				}
+				database.SetSlackUserStatus(info.ID, info.Profile.StatusText, info.Profile.StatusEmoji, int64(info.Profile.StatusExpiration))
+			}
+		}
+
+		for _, user := range users {
+			presence, err := slackClient.GetUserPresence(user)
+			if err != nil {
+				log.Error("error fetching presence", "user", user, "err", err)
+				break
+			}
+			if cached, ok := database.CachedSlackUser(user); ok && cached.Presence != presence.Presence {
+				changed = true
+			}
+			database.SetSlackUserPresence(user, presence.Presence)
+		}
+
+		return presenceUpdate{changed, scheduled}
+	}
+}
+
+// redraws everything that shows someone's presence, cached bodies included since senders are in them
+func (m *Model) refreshPresence() {
+	for i := range m.tabs {
+		t := &m.tabs[i]
+		if t.channel == "" {
+			continue
+		}
+		t.rendered = map[string]string{}
+		m.refreshMessagePager(i)
+	}
+	m.refreshConversationLists()
+}
+
+// only standard emoji, custom ones are images and don't fit on a line of text
+func statusEmoji(name string) string {
+	name = strings.Trim(name, ":")
+	if glyph, ok := utils.StandardEmoji[name]; ok {
+		return glyph
+	}
+	if name == "" {
+		return ""
+	}
+	return ":" + name + ":"
+}
+
+// the dot and status that go after someone's name
+func presenceSuffix(user database.SlackUserMap) string {
+	suffix := ""
+	switch user.Presence {
+	case "active":
+		suffix += " " + activeDotStyle.Render("●")
+	case "away":
+		suffix += " " + mutedStyle.Render("○")
+	}
+
+	emoji, text := user.Status()
+	if status := strings.TrimSpace(statusEmoji(emoji) + " " + text); status != "" {
+		suffix += " " + lessMutedStyle.Render(status)
+	}
+
+	return suffix
+}
+
+// how long a status lasts, until is worked out when it's saved
+type statusExpiry struct {
+	label string
+	until func(now time.Time) int64
+}
+
+var statusExpiries = []statusExpiry{
+	{"don't clear", func(time.Time) int64 { return 0 }},
+	{"30 minutes", func(now time.Time) int64 { return now.Add(30 * time.Minute).Unix() }},
+	{"1 hour", func(now time.Time) int64 { return now.Add(time.Hour).Unix() }},
+	{"4 hours", func(now time.Time) int64 { return now.Add(4 * time.Hour).Unix() }},
+	{"today", func(now time.Time) int64 {
+		return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Unix()
+	}},
+	{"this week", func(now time.Time) int64 {
+		return time.Date(now.Year(), now.Month(), now.Day()+7-int(now.Weekday()), 0, 0, 0, 0, now.Location()).Unix()
+	}},
+}
+
+// the status editor's inputs, field is which of text, emoji, expiry and presence has focus
+type statusEditor struct {
+	text  textinput.Model
+	emoji textinput.Model
+	field int
+	// an index into statusExpiries, or -1 to keep the expiration the status already had
+	expiry     int
+	expiration int64
+	away       bool
+	// the user's utc offset in seconds so today means their today
+	tzOffset int
+	// the state to go back to once we're done
+	returnState string
+	// set once anything has been changed so loading what slack has doesn't undo it
+	dirty bool
+}
+
+type ownStatusUpdate struct {
+	tab        int
+	text       string
+	emoji      string
+	expiration int64
+	away       bool
+	tzOffset   int
+}
+
+type statusSavedUpdate struct {
+	tab int
+}
+
+func getOwnStatus(slackClient *slack.Client, userID string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		info, err := slackClient.GetUserInfo(userID)
+		if err != nil {
+			log.Error("error fetching your status", "err", err)
+			return statusUpdate{tab, "couldn't load your status: " + err.Error()}
+		}
+		presence, err := slackClient.GetUserPresence(userID)
+		if err != nil {
+			log.Error("error fetching your presence", "err", err)
+			return statusUpdate{tab, "couldn't load your presence: " + err.Error()}
+		}
+
+		return ownStatusUpdate{tab, info.Profile.StatusText, info.Profile.StatusEmoji, int64(info.Profile.StatusExpiration), presence.Presence == "away", info.TZOffset}
+	}
+}
+
+func saveOwnStatus(slackClient *slack.Client, userID string, editor statusEditor, wasAway bool, tab int) tea.Cmd {
+	emoji := strings.Trim(strings.TrimSpace(editor.emoji.Value()), ":")
+	if emoji != "" {
+		emoji = ":" + emoji + ":"
+	}
+	text := strings.TrimSpace(editor.text.Value())
+
+	expiration := editor.expiration
+	if editor.expiry >= 0 {
+		expiration = statusExpiries[editor.expiry].until(time.Now().In(time.FixedZone("", editor.tzOffset)))
+	}
+	if text == "" && emoji == "" {
+		expiration = 0
+	}
+
+	return func() tea.Msg {
+		if err := slackClient.SetUserCustomStatus(text, emoji, expiration); err != nil {
+			log.Error("error setting your status", "err", err)
+			return statusUpdate{tab, "couldn't set your status: " + err.Error()}
+		}
+		database.SetSlackUserStatus(userID, text, emoji, expiration)
+
+		// slack only lets you force away or go back to it working presence out itself
+		if editor.away != wasAway {
+			presence := "auto"
+			if editor.away {
+				presence = "away"
+			}
+			if err := slackClient.SetUserPresence(presence); err != nil {
+				log.Error("error setting your presence", "err", err)
+				return statusUpdate{tab, "couldn't set your presence: " + err.Error()}
+			}
+		}
+
+		return statusSavedUpdate{tab}
+	}
+}
+
+func (m Model) openStatusEditor(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	cached := database.QuerySlackUserID(m.userID)
+
+	editor := statusEditor{
+		text:        t.reactionInput,
+		emoji:       t.reactionInput,
+		expiry:      0,
+		away:        cached.Presence == "away",
+		returnState: t.state,
+	}
+	// the labels already point at whichever field has focus
+	editor.text.Prompt, editor.emoji.Prompt = "", ""
+	editor.text.Placeholder = "what's your status?"
+	editor.text.CharLimit = 100
+	editor.emoji.Placeholder = "emoji, like :palm_tree:"
+	editor.text.SetValue(cached.StatusText)
+	editor.emoji.SetValue(cached.StatusEmoji)
+	editor.emoji.Blur()
+	if cached.StatusExpiration != 0 {
+		editor.expiry, editor.expiration = -1, cached.StatusExpiration
+	}
+
+	t.statusEditor = editor
+	t.status = "loading your status..."
+	t.state = "editStatus"
+
+	return tea.Batch(t.statusEditor.text.Focus(), getOwnStatus(m.slackClient, m.userID, tab))
+}
+
+// fills in whatever slack has if the editor hasn't been touched in the meantime
+func (m Model) applyOwnStatus(msg ownStatusUpdate) {
+	t := &m.tabs[msg.tab]
+	if t.state != "editStatus" {
+		return
+	}
+	t.status = ""
+
+	editor := &t.statusEditor
+	editor.tzOffset = msg.tzOffset
+	if editor.dirty {
+		return
+	}
+	editor.away = msg.away
+	editor.text.SetValue(msg.text)
+	editor.emoji.SetValue(msg.emoji)
+	editor.expiry, editor.expiration = 0, 0
+	if msg.expiration != 0 {
+		editor.expiry, editor.expiration = -1, msg.expiration
+	}
+}
+
+func (m Model) saveStatusEditor(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.status = "saving your status..."
+	return saveOwnStatus(m.slackClient, m.userID, t.statusEditor, database.QuerySlackUserID(m.userID).Presence == "away", tab)
+}
+
+// moves focus between the editor's fields, the text boxes only blink while they have it
+func (m Model) focusStatusField(tab int, delta int) tea.Cmd {
+	editor := &m.tabs[tab].statusEditor
+	editor.field = (editor.field + delta + 4) % 4
+	editor.text.Blur()
+	editor.emoji.Blur()
+
+	switch editor.field {
+	case 0:
+		return editor.text.Focus()
+	case 1:
+		return editor.emoji.Focus()
+	}
+	return nil
+}
+
+func (m Model) updateStatusEditor(tab int, msg tea.Msg) tea.Cmd {
+	editor := &m.tabs[tab].statusEditor
+
+	keyMsg, isKey := msg.(tea.KeyMsg)
+	if isKey && key.Matches(keyMsg, m.keys.Enter) {
+		return nil
+	}
+	if isKey && (keyMsg.String() == "up" || keyMsg.String() == "down") {
+		if keyMsg.String() == "up" {
+			return m.focusStatusField(tab, -1)
+		}
+		return m.focusStatusField(tab, 1)
+	}
+
+	if isKey {
+		editor.dirty = true
+	}
+
+	var cmd tea.Cmd
+	switch editor.field {
+	case 0:
+		editor.text, cmd = editor.text.Update(msg)
+	case 1:
+		editor.emoji, cmd = editor.emoji.Update(msg)
+	case 2:
+		if !isKey {
+			break
+		}
+		// the expiry we started with sits before the first choice
+		first := 0
+		if editor.expiration != 0 {
+			first = -1
+		}
+		switch keyMsg.String() {
+		case "left":
+			editor.expiry = max(editor.expiry-1, first)
+		case "right", " ":
+			editor.expiry = min(editor.expiry+1, len(statusExpiries)-1)
+		}
+	case 3:
+		if isKey && (keyMsg.String() == "left" || keyMsg.String() == "right" || keyMsg.String() == " ") {
+			editor.away = !editor.away
+		}
+	}
+
+	return cmd
+}
+
+func statusEditorView(style lipgloss.Style, m Model) string {
+	t := m.tabs[m.activeTab]
+	editor := t.statusEditor
+
+	label := func(field int, text string) string {
+		if editor.field == field {
+			return selectedItemStyle.Render("> " + text)
+		}
+		return itemStyle.Render(text)
+	}
+
+	expiry := "until " + time.Unix(editor.expiration, 0).In(time.FixedZone("", editor.tzOffset)).Format("Jan 2 15:04")
+	if editor.expiry >= 0 {
+		expiry = statusExpiries[editor.expiry].label
+	}
+	presence := "(x) automatic  ( ) away"
+	if editor.away {
+		presence = "( ) automatic  (x) away"
+	}
+
+	return style.Render(lessMutedStyle.Render("set your status") + "\n\n" +
+		label(0, "status") + "\n" + itemStyle.Render(editor.text.View()) + "\n\n" +
+		label(1, "emoji") + "\n" + itemStyle.Render(editor.emoji.View()) + "\n\n" +
+		label(2, "clear after") + "\n" + itemStyle.Render(evenLessMutedStyle.Render("< "+expiry+" >")) + "\n\n" +
+		label(3, "presence") + "\n" + itemStyle.Render(evenLessMutedStyle.Render(presence)) + "\n\n" +
+		statusView(m) +
+		lessMutedStyle.Render("up and down to move, left and right to choose, enter to save, ctrl+b to go back. empty the status and emoji to clear it"))
+}
@@ -0,0 +1,137 @@
+package bubbleViews
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// how wide the avatar is drawn in pixels
+const avatarWidth = 96
+
+type profileUpdate struct {
+	tab    int
+	user   string
+	avatar string
+}
+
+// slack-go's profile leaves pronouns out so ask for them ourselves
+func getPronouns(token string, userID string) (string, error) {
+	req, err := http.NewRequest("GET", "https://slack.com/api/users.profile.get?user="+userID, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var profile struct {
+		Profile struct {
+			Pronouns string `json:"pronouns"`
+		} `json:"profile"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return "", err
+	}
+
+	return profile.Profile.Pronouns, nil
+}
+
+// refreshes everything on the card and draws the avatar, which is slow enough to want its own command
+func getProfile(slackClient *slack.Client, token string, userID string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		user, err := database.RefreshSlackUser(userID, *slackClient)
+		if err != nil {
+			log.Error("error fetching profile", "user", userID, "err", err)
+			return statusUpdate{tab, "couldn't load their profile: " + err.Error()}
+		}
+
+		if pronouns, err := getPronouns(token, userID); err != nil {
+			log.Error("error fetching pronouns", "user", userID, "err", err)
+		} else {
+			database.SetSlackUserPronouns(userID, pronouns)
+		}
+
+		avatar := ""
+		if user.Avatar != "" {
+			avatar = utils.SixelEncode(user.Avatar, avatarWidth)
+		}
+
+		return profileUpdate{tab, userID, avatar}
+	}
+}
+
+func hasSender(_ Model, message slack.Message) bool {
+	return message.User != ""
+}
+
+func profileAction(m Model, tab int, message slack.Message) tea.Cmd {
+	t := &m.tabs[tab]
+	t.state = "profile"
+	t.profileUser = message.User
+	t.profileAvatar = ""
+	t.status = ""
+
+	return getProfile(m.slackClient, database.DB.ApplicationData[m.user].SlackToken, message.User, tab)
+}
+
+func (m Model) messageProfileUser(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.status = "opening the dm..."
+	return openDirectMessage(m.slackClient, []string{t.profileUser}, m.userID, tab)
+}
+
+// their time of day, worked out from the zone name with the offset as a fallback
+func localTime(user database.SlackUserMap, now time.Time) time.Time {
+	if location, err := time.LoadLocation(user.TZ); err == nil && user.TZ != "" {
+		return now.In(location)
+	}
+	return now.In(time.FixedZone("", user.TZOffset))
+}
+
+func profileView(m Model) string {
+	t := m.tabs[m.activeTab]
+	user := database.QuerySlackUserID(t.profileUser)
+
+	row := func(label string, value string) string {
+		if value == "" {
+			return ""
+		}
+		return itemStyle.Render(lessMutedStyle.Render(label+" ")+evenLessMutedStyle.Render(value)) + "\n"
+	}
+
+	var b strings.Builder
+	if t.profileAvatar != "" {
+		b.WriteString(itemStyle.Render(t.profileAvatar) + "\n\n")
+	}
+
+	name := user.DisplayName
+	if name == "" {
+		name = user.RealName
+	}
+	b.WriteString(itemStyle.Render(highlightedStyle.Render("@"+name)+presenceSuffix(user)) + "\n\n")
+	b.WriteString(row("name", user.RealName))
+	b.WriteString(row("pronouns", user.Pronouns))
+	b.WriteString(row("title", user.Title))
+	b.WriteString(row("email", user.Email))
+	if user.TZ != "" || user.TZOffset != 0 {
+		b.WriteString(row("local time", localTime(user, time.Now()).Format("15:04 Mon")+" ("+user.TZ+")"))
+	}
+	emoji, text := user.Status()
+	b.WriteString(row("status", strings.TrimSpace(statusEmoji(emoji)+" "+text)))
+
+	return b.String() + "\n" + statusView(m) +
+		lessMutedStyle.Render("enter to message them, ctrl+b to go back")
+}
@@ -0,0 +1,192 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/events"
+	"charming-slack/libs/utils"
+)
+
+// how many emoji the picker lists under the input at once
+const maxEmojiSuggestions = 8
+
+var reactedStyle = highlightedStyle.
+	Underline(true)
+
+// slack tacks the skin tone onto the name, e.g. thumbsup::skin-tone-2
+func baseEmojiName(name string) string {
+	base, _, _ := strings.Cut(name, "::")
+	return base
+}
+
+// the chips shown under a message, ours are highlighted
+func (m Model) renderReactions(message slack.Message) string {
+	chips := []string{}
+	for _, reaction := range message.Reactions {
+		chip := fmt.Sprintf(":%s: %d", baseEmojiName(reaction.Name), reaction.Count)
+		if slices.Contains(reaction.Users, m.userID) {
+			chips = append(chips, reactedStyle.Render(chip))
+		} else {
+			chips = append(chips, lessMutedStyle.Render(chip))
+		}
+	}
+
+	return "  " + strings.Join(chips, "  ")
+}
+
+// every emoji name matching the query, prefix matches before the rest
+func emojiSuggestions(query string) []string {
+	query = strings.ToLower(strings.Trim(query, ": "))
+	if query == "" {
+		return nil
+	}
+
+	names := database.EmojiNames()
+	for name := range utils.StandardEmoji {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	prefixed := []string{}
+	contained := []string{}
+	for _, name := range names {
+		switch {
+		case strings.HasPrefix(name, query):
+			prefixed = append(prefixed, name)
+		case strings.Contains(name, query):
+			contained = append(contained, name)
+		}
+	}
+
+	suggestions := append(prefixed, contained...)
+	if len(suggestions) > maxEmojiSuggestions {
+		suggestions = suggestions[:maxEmojiSuggestions]
+	}
+
+	return suggestions
+}
+
+func reactAction(m Model, tab int, _ slack.Message) tea.Cmd {
+	t := &m.tabs[tab]
+	t.state = "react"
+	t.reactionInput.Placeholder = "search emoji, like thumbsup"
+	t.reactionInput.SetValue("")
+	t.reactionCursor = 0
+
+	return t.reactionInput.Focus()
+}
+
+func hasReacted(message slack.Message, name string, userID string) bool {
+	for _, reaction := range message.Reactions {
+		if baseEmojiName(reaction.Name) == name && slices.Contains(reaction.Users, userID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// adds the picked emoji to the selected message, or takes it off if it's already ours
+func (m Model) toggleReaction(tab int) tea.Cmd {
+	t := &m.tabs[tab]
+	t.state = "messages"
+
+	message, ok := t.actionTarget()
+	if !ok {
+		return nil
+	}
+
+	name := strings.Trim(t.reactionInput.Value(), ": ")
+	if suggestions := emojiSuggestions(name); t.reactionCursor < len(suggestions) {
+		name = suggestions[t.reactionCursor]
+	}
+	if name == "" {
+		return nil
+	}
+
+	remove := hasReacted(message, name, m.userID)
+	slackClient := *m.slackClient
+	channel := t.channel
+	userID := m.userID
+	return func() tea.Msg {
+		ref := slack.NewRefToMessage(channel, message.Timestamp)
+
+		var err error
+		kind := events.ReactionAdded
+		if remove {
+			err = slackClient.RemoveReaction(name, ref)
+			kind = events.ReactionRemoved
+		} else {
+			err = slackClient.AddReaction(name, ref)
+		}
+		if err != nil {
+			log.Error("error updating reaction", "err", err)
+			return statusUpdate{tab, "couldn't react: " + err.Error()}
+		}
+
+		return localEventUpdate{events.Event{Kind: kind, Channel: channel, Message: message, Reaction: name, User: userID}}
+	}
+}
+
+// returns a copy of the message with the user's reaction added or removed
+func withReaction(message slack.Message, name string, userID string, add bool) slack.Message {
+	reactions := []slack.ItemReaction{}
+	found := false
+	for _, reaction := range message.Reactions {
+		if baseEmojiName(reaction.Name) != baseEmojiName(name) {
+			reactions = append(reactions, reaction)
+			continue
+		}
+
+		found = true
+		users := slices.DeleteFunc(slices.Clone(reaction.Users), func(user string) bool { return user == userID })
+		if add {
+			users = append(users, userID)
+		}
+		reaction.Count += len(users) - len(reaction.Users)
+		reaction.Users = users
+		if reaction.Count > 0 {
+			reactions = append(reactions, reaction)
+		}
+	}
+
+	if !found && add {
+		reactions = append(reactions, slack.ItemReaction{Name: name, Count: 1, Users: []string{userID}})
+	}
+
+	message.Reactions = reactions
+	return message
+}
+
+func reactView(m Model) string {
+	t := m.tabs[m.activeTab]
+
+	message, _ := t.actionTarget()
+
+	var b strings.Builder
+	for i, name := range emojiSuggestions(t.reactionInput.Value()) {
+		label := ":" + name + ":"
+		if unicode, ok := utils.StandardEmoji[name]; ok {
+			label = unicode + " " + label
+		}
+		if hasReacted(message, name, m.userID) {
+			label += " (remove)"
+		}
+
+		if i == t.reactionCursor {
+			b.WriteString("\n" + selectedItemStyle.Render("> "+label))
+		} else {
+			b.WriteString("\n" + itemStyle.Render(label))
+		}
+	}
+
+	return t.messagePager.View() + "\n" + lessMutedStyle.Render("react to the selected message, ↑/↓ to pick (ctrl+b to cancel)") + "\n" + t.reactionInput.View() + b.String()
+}
@@ -0,0 +1,412 @@
+package bubbleViews
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// the saved tab sits after search
+const savedTab = 4
+
+// pins.list is one call per channel, so only the channels visited most are checked for pins
+const maxPinChannels = 20
+
+type savedItem struct {
+	// saved or pinned
+	kind    string
+	channel string
+	message slack.Message
+}
+
+// one kind of saved item at a time since pins take a while to gather
+type savedItemsUpdate struct {
+	kind  string
+	items []savedItem
+	// which load these came from, anything older than the tab's latest is thrown away
+	generation int
+	// slack started rate limiting before every channel's pins were in
+	limited bool
+}
+
+// sent once a message has been pinned, saved or had either undone
+type messageFlagUpdate struct {
+	tab       int
+	channel   string
+	timestamp string
+	// pinned or saved
+	flag string
+	set  bool
+}
+
+func getStarredItems(slackClient *slack.Client, generation int) tea.Cmd {
+	return func() tea.Msg {
+		items := []savedItem{}
+		for page := 1; ; page++ {
+			stars, paging, err := slackClient.ListStars(slack.StarsParameters{Count: 100, Page: page})
+			if err != nil {
+				log.Error("error fetching saved items", "err", err)
+				return statusUpdate{savedTab, "couldn't load saved items: " + err.Error()}
+			}
+
+			// files and whole channels can be starred too but there's nothing to jump to for those
+			for _, star := range stars {
+				if star.Type == slack.TYPE_MESSAGE && star.Message != nil {
+					utils.CacheUsers(*slackClient, star.Message.User, star.Message.Text)
+					items = append(items, savedItem{"saved", star.Channel, *star.Message})
+				}
+			}
+
+			if paging == nil || page >= paging.Pages {
+				break
+			}
+		}
+
+		return savedItemsUpdate{kind: "saved", items: items, generation: generation}
+	}
+}
+
+// pins.list is rate limited per minute and gets called once per channel, rather than wait
+// it out this hands back what it has and the rest can be fetched with a refresh later
+func getPinnedItems(slackClient *slack.Client, channels []slack.Channel, generation int) tea.Cmd {
+	return func() tea.Msg {
+		items := []savedItem{}
+		for _, channel := range channels {
+			pins, _, err := slackClient.ListPins(channel.ID)
+			var rateLimited *slack.RateLimitedError
+			if errors.As(err, &rateLimited) {
+				log.Warn("rate limited fetching pins", "retry", rateLimited.RetryAfter)
+				return savedItemsUpdate{kind: "pinned", items: items, generation: generation, limited: true}
+			}
+			if err != nil {
+				log.Error("error fetching pins", "channel", channel.ID, "err", err)
+				continue
+			}
+
+			for _, pin := range pins {
+				if pin.Message != nil {
+					utils.CacheUsers(*slackClient, pin.Message.User, pin.Message.Text)
+					items = append(items, savedItem{"pinned", channel.ID, *pin.Message})
+				}
+			}
+		}
+
+		return savedItemsUpdate{kind: "pinned", items: items, generation: generation}
+	}
+}
+
+// the first visit loads everything, after that the items stay until they're refreshed with r
+func (m Model) openSaved() tea.Cmd {
+	if m.tabs[savedTab].savedGeneration > 0 {
+		return nil
+	}
+
+	return m.loadSaved()
+}
+
+func (m Model) loadSaved() tea.Cmd {
+	t := &m.tabs[savedTab]
+	t.status = "loading saved items..."
+	t.savedLoaded = 0
+	t.limitedPins = false
+	t.savedGeneration++
+
+	return tea.Batch(getStarredItems(m.slackClient, t.savedGeneration), getPinnedItems(m.slackClient, m.pinChannels(), t.savedGeneration))
+}
+
+// the channels worth checking for pins, most visited first
+func (m Model) pinChannels() []slack.Channel {
+	channels := append(slices.Clone(m.channels), m.privateChannels...)
+	frecency := database.Frecency(m.user)
+	slices.SortStableFunc(channels, func(a, b slack.Channel) int {
+		switch {
+		case frecency[a.ID] > frecency[b.ID]:
+			return -1
+		case frecency[a.ID] < frecency[b.ID]:
+			return 1
+		}
+		return 0
+	})
+
+	return channels[:min(len(channels), maxPinChannels)]
+}
+
+// swaps in the fresh items of one kind, saved items are listed before pins
+func (m Model) applySavedItems(msg savedItemsUpdate) {
+	t := &m.tabs[savedTab]
+	if msg.generation != t.savedGeneration {
+		return
+	}
+
+	t.savedItems = slices.DeleteFunc(t.savedItems, func(item savedItem) bool {
+		return item.kind == msg.kind
+	})
+	if msg.kind == "saved" {
+		t.savedItems = append(msg.items, t.savedItems...)
+	} else {
+		t.savedItems = append(t.savedItems, msg.items...)
+	}
+
+	t.savedLoaded++
+	if msg.limited {
+		t.limitedPins = true
+	}
+	if t.savedLoaded >= 2 {
+		t.status = ""
+		if t.limitedPins {
+			t.status = "slack cut the pins short, press r in a minute to get the rest"
+		}
+	}
+	t.savedCursor = min(t.savedCursor, max(len(t.savedItems)-1, 0))
+}
+
+func (m Model) updateSaved(msg tea.KeyMsg) tea.Cmd {
+	t := &m.tabs[savedTab]
+	switch msg.String() {
+	case "r":
+		return m.loadSaved()
+	case "up", "k":
+		t.savedCursor = max(t.savedCursor-1, 0)
+	case "down", "j":
+		t.savedCursor = max(min(t.savedCursor+1, len(t.savedItems)-1), 0)
+	}
+
+	return nil
+}
+
+func (m *Model) openSavedItem() tea.Cmd {
+	t := m.tabs[savedTab]
+	if t.savedCursor >= len(t.savedItems) {
+		return nil
+	}
+
+	item := t.savedItems[t.savedCursor]
+	thread := ""
+	if item.message.ThreadTimestamp != "" && item.message.ThreadTimestamp != item.message.Timestamp {
+		thread = item.message.ThreadTimestamp
+	}
+
+	return m.openConversation(item.channel, item.message.Timestamp, thread)
+}
+
+// where a conversation lives, anything we can't find is assumed to be a public channel
+func (m Model) conversationTab(channel string) int {
+	for tab, channels := range [][]slack.Channel{m.channels, m.privateChannels, m.dms} {
+		if slices.ContainsFunc(channels, func(c slack.Channel) bool { return c.ID == channel }) {
+			return tab
+		}
+	}
+
+	return 0
+}
+
+// a readable name for any conversation we know about
+func (m Model) conversationName(channel string) string {
+	if i := slices.IndexFunc(m.dms, func(c slack.Channel) bool { return c.ID == channel }); i != -1 && i < len(m.dmNames) {
+		return m.dmNames[i]
+	}
+
+	return "#" + m.channelName(channel)
+}
+
+// switches to the conversation's own tab and opens it there with the message selected,
+// replies open straight into their thread. without a message to jump to we're there to write
+func (m *Model) openConversation(channel string, timestamp string, thread string) tea.Cmd {
+	return m.openConversationIn(m.conversationTab(channel), channel, timestamp, thread)
+}
+
+// the same as openConversation for a conversation the lists don't know about yet
+func (m *Model) openConversationIn(tab int, channel string, timestamp string, thread string) tea.Cmd {
+	t := &m.tabs[tab]
+
+	if t.channel != "" && t.channel != channel {
+		m.events.Unsubscribe(t.channel)
+	}
+	t.channel = channel
+	t.state = "messages"
+	t.focused = 0
+	m.recordVisit(channel)
+	t.jumpTo = timestamp
+	t.editTimestamp = ""
+	m.restoreDraft(tab)
+	t.status = ""
+	t.threadTimestamp = ""
+	t.threadMessages = nil
+	t.alsoSendToChannel = false
+	m.activeTab = tab
+
+	cmds := []tea.Cmd{}
+	if timestamp == "" {
+		t.focused = 1
+		cmds = append(cmds, t.messageInput.Focus())
+	}
+	if t.showInfo {
+		t.info = nil
+		cmds = append(cmds, getChannelInfo(m.slackClient, channel, tab))
+	}
+	if thread != "" {
+		t.state = "thread"
+		t.threadTimestamp = thread
+		t.jumpTo = thread
+		cmds = append(cmds, getThread(m.slackClient, channel, thread, tab))
+	}
+
+	// the history is loaded around the message so it's there however old it is
+	if t.jumpTo != "" {
+		cmds = append(cmds, getMessagesAround(m.slackClient, channel, t.jumpTo, tab))
+	} else {
+		cmds = append(cmds, getMessages(m.slackClient, channel, tab))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// selects the message we were asked to jump to once its conversation has loaded
+func (m Model) jumpToMessage(tab int) {
+	t := &m.tabs[tab]
+	if t.jumpTo == "" {
+		return
+	}
+
+	index := slices.IndexFunc(t.messages, func(message slack.Message) bool {
+		return message.Timestamp == t.jumpTo
+	})
+	t.jumpTo = ""
+	if index == -1 {
+		t.status = "that message is further back than what's loaded"
+		return
+	}
+
+	t.cursor = index
+	m.moveCursor(tab, 0)
+}
+
+func pinned(_ Model, message slack.Message) bool {
+	return len(message.PinnedTo) > 0
+}
+
+func notPinned(m Model, message slack.Message) bool {
+	return !pinned(m, message)
+}
+
+func saved(_ Model, message slack.Message) bool {
+	return message.IsStarred
+}
+
+func notSaved(m Model, message slack.Message) bool {
+	return !saved(m, message)
+}
+
+// pins and saves share everything but the call they make
+func flagAction(flag string, set bool) func(m Model, tab int, message slack.Message) tea.Cmd {
+	return func(m Model, tab int, message slack.Message) tea.Cmd {
+		slackClient := *m.slackClient
+		channel := m.tabs[tab].channel
+		return func() tea.Msg {
+			ref := slack.NewRefToMessage(channel, message.Timestamp)
+
+			var err error
+			switch {
+			case flag == "pinned" && set:
+				err = slackClient.AddPin(channel, ref)
+			case flag == "pinned":
+				err = slackClient.RemovePin(channel, ref)
+			case set:
+				err = slackClient.AddStar(channel, ref)
+			default:
+				err = slackClient.RemoveStar(channel, ref)
+			}
+			if err != nil {
+				log.Error("error changing message", "flag", flag, "set", set, "err", err)
+				return statusUpdate{tab, "couldn't change that message: " + err.Error()}
+			}
+
+			return messageFlagUpdate{tab, channel, message.Timestamp, flag, set}
+		}
+	}
+}
+
+func (m Model) applyMessageFlag(msg messageFlagUpdate) {
+	for i := range m.tabs {
+		t := &m.tabs[i]
+		if t.channel != msg.channel {
+			continue
+		}
+
+		index := slices.IndexFunc(t.messages, func(message slack.Message) bool {
+			return message.Timestamp == msg.timestamp
+		})
+		if index == -1 {
+			continue
+		}
+
+		message := &t.messages[index]
+		if msg.flag == "pinned" {
+			message.PinnedTo = nil
+			if msg.set {
+				message.PinnedTo = []string{msg.channel}
+			}
+		} else {
+			message.IsStarred = msg.set
+		}
+		delete(t.rendered, msg.timestamp)
+		m.refreshMessagePager(i)
+	}
+
+	status := map[string]string{"pinned": "pinned message", "saved": "saved for later"}[msg.flag]
+	if !msg.set {
+		status = map[string]string{"pinned": "unpinned message", "saved": "removed from saved"}[msg.flag]
+	}
+	m.tabs[msg.tab].status = status
+}
+
+func savedView(style lipgloss.Style, m Model) string {
+	t := m.tabs[savedTab]
+
+	var b strings.Builder
+	if len(t.savedItems) == 0 && t.savedLoaded >= 2 {
+		b.WriteString(mutedStyle.Render("  nothing saved or pinned yet, save messages from their actions menu"))
+	}
+
+	// each item takes two lines, scroll a page at a time to keep the cursor in view
+	rows := max((style.GetHeight()-4)/2, 1)
+	start := t.savedCursor / rows * rows
+	for i := start; i < min(start+rows, len(t.savedItems)); i++ {
+		item := t.savedItems[i]
+
+		sender := database.QuerySlackUserID(item.message.User)
+		name := sender.DisplayName
+		if name == "" {
+			name = sender.RealName
+		}
+		details := "  " + item.kind + " · @" + name
+		if seconds, err := strconv.ParseInt(strings.Split(item.message.Timestamp, ".")[0], 10, 64); err == nil {
+			details += " · " + time.Unix(seconds, 0).Format(time.DateTime)
+		}
+
+		text := utils.ClampString(strings.ReplaceAll(item.message.Text, "\n", " "), m.width-16)
+		text = utils.CachedUserIdParser(text, lessMutedStyle, lessMutedStyle)
+
+		if i == t.savedCursor {
+			b.WriteString(selectedItemStyle.Render("> "+m.conversationName(item.channel)) + lessMutedStyle.Render(details) + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(m.conversationName(item.channel)) + lessMutedStyle.Render(details) + "\n")
+		}
+		b.WriteString(itemStyle.Render(mutedStyle.Render(text)) + "\n")
+	}
+
+	list := lipgloss.NewStyle().Height(rows * 2).Render(b.String())
+	hint := lessMutedStyle.Render("enter to jump to the conversation, r to refresh")
+
+	return style.Render(list + "\n" + statusView(m) + hint)
+}
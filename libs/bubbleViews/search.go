@@ -0,0 +1,450 @@
+package bubbleViews
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// the search tab's index
+const searchTab = 3
+
+const searchPageSize = 20
+
+// slack wraps the words a result matched in these when asked to highlight them
+const (
+	highlightStart = "\ue000"
+	highlightEnd   = "\ue001"
+)
+
+// only reverse video is switched on and off so whatever glamour styled the word with survives
+var matchHighlighter = strings.NewReplacer(highlightStart, "\x1b[7m", highlightEnd, "\x1b[27m")
+
+var searchHas = []string{"anything", "link", "file"}
+
+// what the search tab looks through
+var searchModes = []string{"messages", "files", "offline messages"}
+
+const filesMode = 1
+
+var searchSorts = []struct {
+	label string
+	sort  string
+}{
+	{"most relevant", "score"},
+	{"newest first", "timestamp"},
+}
+
+// the fields of the search form below the query itself, which stays in Model.searchInput
+type searchForm struct {
+	in     textinput.Model
+	from   textinput.Model
+	after  textinput.Model
+	before textinput.Model
+	has    int
+	sort   int
+	mode   int
+	// which row has focus, from query down to mode
+	field int
+}
+
+const searchFields = 8
+
+func newSearchForm(input textinput.Model) searchForm {
+	form := searchForm{in: input, from: input, after: input, before: input}
+	form.in.Placeholder = "#channel"
+	form.from.Placeholder = "@someone"
+	form.after.Placeholder = "yyyy-mm-dd"
+	form.before.Placeholder = "yyyy-mm-dd"
+	for _, field := range []*textinput.Model{&form.in, &form.from, &form.after, &form.before} {
+		field.Prompt = ""
+		field.Blur()
+	}
+
+	return form
+}
+
+type searchMessageUpdate struct {
+	messages []slack.SearchMessage
+	page     int
+	pages    int
+	total    int
+}
+
+func searchMessages(slackClient *slack.Client, query string, sort string, page int) tea.Cmd {
+	return func() tea.Msg {
+		messages, err := slackClient.SearchMessages(query, slack.SearchParameters{Count: searchPageSize, Page: page, Sort: sort, SortDirection: "desc", Highlight: true})
+		if err != nil {
+			log.Error("error searching messages", "err", err)
+
+			return statusUpdate{searchTab, "search failed: " + err.Error()}
+		}
+		for _, message := range messages.Matches {
+			utils.CacheUsers(*slackClient, message.User, message.Text)
+		}
+
+		return searchMessageUpdate{messages: messages.Matches, page: messages.Paging.Page, pages: messages.Paging.Pages, total: messages.Pagination.TotalCount}
+	}
+}
+
+// turns the form into slack's search modifiers, names are resolved to ids where we can
+func (m Model) compileSearch() (string, error) {
+	form := m.tabs[searchTab].searchForm
+	channels := append(slices.Clone(m.channels), m.privateChannels...)
+
+	parts := []string{}
+	if query := strings.TrimSpace(m.searchInput.Value()); query != "" {
+		parts = append(parts, query)
+	}
+
+	if in := strings.TrimSpace(form.in.Value()); in != "" {
+		// a person means the dm with them, anything else is a channel
+		if !strings.HasPrefix(in, "@") {
+			in = "#" + strings.TrimPrefix(in, "#")
+		}
+		encoded, _ := utils.EncodeMentions(in, m.directory, channels)
+		parts = append(parts, "in:"+encoded)
+	}
+	if from := strings.TrimSpace(form.from.Value()); from != "" {
+		encoded, _ := utils.EncodeMentions("@"+strings.TrimPrefix(from, "@"), m.directory, channels)
+		parts = append(parts, "from:"+encoded)
+	}
+	if form.has != 0 {
+		parts = append(parts, "has:"+searchHas[form.has])
+	}
+	for _, date := range []struct {
+		modifier string
+		input    textinput.Model
+	}{{"after", form.after}, {"before", form.before}} {
+		value := strings.TrimSpace(date.input.Value())
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return "", fmt.Errorf("%s needs a date like 2024-01-31", date.modifier)
+		}
+		parts = append(parts, date.modifier+":"+value)
+	}
+
+	if len(parts) == 0 {
+		return "", fmt.Errorf("type something to search for or fill in a filter")
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func (m Model) runSearch(page int) tea.Cmd {
+	t := &m.tabs[searchTab]
+	query, err := m.compileSearch()
+	if err != nil {
+		t.status = err.Error()
+		return nil
+	}
+	if t.searchForm.mode == localMode {
+		if _, err := m.localSearchQuery(); err != nil {
+			t.status = err.Error()
+			return nil
+		}
+	}
+
+	t.state = "view"
+	t.status = "searching..."
+	t.searchQuery = query
+	t.searchCursor = 0
+	return m.fetchSearchPage(page)
+}
+
+// asks for a page of whatever the last search looked for
+func (m Model) fetchSearchPage(page int) tea.Cmd {
+	t := m.tabs[searchTab]
+	sort := searchSorts[t.searchForm.sort].sort
+	switch t.searchForm.mode {
+	case filesMode:
+		return searchFiles(m.slackClient, t.searchQuery, sort, page)
+	case localMode:
+		return m.searchLocal(page)
+	}
+	return searchMessages(m.slackClient, t.searchQuery, sort, page)
+}
+
+// flips through result pages and picks a result while looking at them
+func (m Model) updateSearchResults(msg tea.KeyMsg) (bool, tea.Cmd) {
+	t := &m.tabs[searchTab]
+	switch msg.String() {
+	case "up", "k":
+		if t.searchForm.mode == filesMode {
+			m.updateSearchFiles(msg)
+		} else {
+			m.moveSearchCursor(-1)
+		}
+		return true, nil
+	case "down", "j":
+		if t.searchForm.mode == filesMode {
+			m.updateSearchFiles(msg)
+		} else {
+			m.moveSearchCursor(1)
+		}
+		return true, nil
+	case "]":
+		if t.searchPage < t.searchPages {
+			t.status = "loading the next page..."
+			return true, m.fetchSearchPage(t.searchPage + 1)
+		}
+		return true, nil
+	case "[":
+		if t.searchPage > 1 {
+			t.status = "loading the previous page..."
+			return true, m.fetchSearchPage(t.searchPage - 1)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// moves between the form's rows, only the one with focus shows a cursor
+func (m *Model) focusSearchField(delta int) tea.Cmd {
+	form := &m.tabs[searchTab].searchForm
+	form.field = (form.field + delta + searchFields) % searchFields
+
+	m.searchInput.Blur()
+	inputs := []*textinput.Model{&m.searchInput, &form.in, &form.from, nil, &form.after, &form.before, nil, nil}
+	for _, input := range inputs {
+		if input != nil {
+			input.Blur()
+		}
+	}
+	if input := inputs[form.field]; input != nil {
+		return input.Focus()
+	}
+	return nil
+}
+
+func (m *Model) updateSearchForm(msg tea.Msg) tea.Cmd {
+	form := &m.tabs[searchTab].searchForm
+
+	keyMsg, isKey := msg.(tea.KeyMsg)
+	if isKey {
+		switch keyMsg.String() {
+		case "up":
+			return m.focusSearchField(-1)
+		case "down":
+			return m.focusSearchField(1)
+		}
+	}
+
+	var cmd tea.Cmd
+	switch form.field {
+	case 0:
+		m.searchInput, cmd = m.searchInput.Update(msg)
+	case 1:
+		form.in, cmd = form.in.Update(msg)
+	case 2:
+		form.from, cmd = form.from.Update(msg)
+	case 3:
+		if isKey {
+			form.has = cycleChoice(form.has, len(searchHas), keyMsg)
+		}
+	case 4:
+		form.after, cmd = form.after.Update(msg)
+	case 5:
+		form.before, cmd = form.before.Update(msg)
+	case 6:
+		if isKey {
+			form.sort = cycleChoice(form.sort, len(searchSorts), keyMsg)
+		}
+	case 7:
+		if isKey {
+			form.mode = cycleChoice(form.mode, len(searchModes), keyMsg)
+		}
+	}
+	m.refreshLocalMatchCount()
+
+	return cmd
+}
+
+func cycleChoice(choice int, count int, msg tea.KeyMsg) int {
+	switch msg.String() {
+	case "left":
+		return (choice - 1 + count) % count
+	case "right", " ":
+		return (choice + 1) % count
+	}
+	return choice
+}
+
+func searchFormView(style lipgloss.Style, m Model) string {
+	t := m.tabs[searchTab]
+	form := t.searchForm
+
+	row := func(field int, label string, value string) string {
+		label = fmt.Sprintf("%-9s", label)
+		if form.field == field {
+			return selectedItemStyle.Render("> "+label) + value + "\n"
+		}
+		return itemStyle.Render(label) + value + "\n"
+	}
+	choice := func(value string) string {
+		return evenLessMutedStyle.Render("< " + value + " >")
+	}
+
+	compiled := ""
+	if query, err := m.compileSearch(); err == nil {
+		compiled = mutedStyle.Render("searches for "+query) + "\n"
+		if form.mode == localMode {
+			compiled = m.tabs[searchTab].localMatches
+		}
+	}
+
+	text := "What do you want to search for?\n\n" +
+		row(0, "search", m.searchInput.View()) +
+		row(1, "in", form.in.View()) +
+		row(2, "from", form.from.View()) +
+		row(3, "has", choice(searchHas[form.has])) +
+		row(4, "after", form.after.View()) +
+		row(5, "before", form.before.View()) +
+		row(6, "sort", choice(searchSorts[form.sort].label)) +
+		row(7, "look for", choice(searchModes[form.mode])) + "\n" +
+		compiled + statusView(m) +
+		lessMutedStyle.Render("up and down to move, left and right to choose, enter to search")
+
+	return style.Render(text)
+}
+
+func (m Model) applySearchResults(msg searchMessageUpdate) {
+	t := &m.tabs[searchTab]
+	// a result without a proper timestamp can't be drawn or jumped to, so it's left out
+	t.searchMessages = slices.DeleteFunc(slices.Clone(msg.messages), func(message slack.SearchMessage) bool {
+		_, err := strconv.ParseInt(strings.Split(message.Timestamp, ".")[0], 10, 64)
+		if err != nil {
+			log.Warn("skipping search result with a malformed timestamp", "channel", message.Channel.ID, "ts", message.Timestamp)
+		}
+		return err != nil
+	})
+	t.searchPage = msg.page
+	t.searchPages = msg.pages
+	t.searchTotal = msg.total
+	t.searchCursor = 0
+	t.rendered = map[string]string{}
+	t.status = ""
+
+	m.refreshSearchPager()
+	t.messagePager.GotoTop()
+}
+
+// moves the selection between results, scrolling just far enough to show the whole of it
+func (m Model) moveSearchCursor(delta int) {
+	t := &m.tabs[searchTab]
+	if len(t.searchMessages) == 0 {
+		return
+	}
+
+	t.searchCursor = min(max(t.searchCursor+delta, 0), len(t.searchMessages)-1)
+	m.refreshSearchPager()
+
+	top := t.offsets[t.searchCursor]
+	bottom := top + lipgloss.Height(t.rendered[searchResultKey(t.searchMessages[t.searchCursor])]) + 2
+	if bottom > t.messagePager.YOffset+t.messagePager.Height {
+		t.messagePager.SetYOffset(bottom - t.messagePager.Height)
+	}
+	if top < t.messagePager.YOffset {
+		t.messagePager.SetYOffset(top)
+	}
+}
+
+// the same timestamp can turn up in more than one channel
+func searchResultKey(message slack.SearchMessage) string {
+	return message.Channel.ID + "/" + message.Timestamp
+}
+
+// opens the selected result's conversation with the history around it loaded, replies open in their thread
+func (m *Model) openSearchResult() tea.Cmd {
+	t := m.tabs[searchTab]
+	if t.searchCursor >= len(t.searchMessages) {
+		return nil
+	}
+
+	message := t.searchMessages[t.searchCursor]
+	thread := ""
+	if permalink, err := url.Parse(message.Permalink); err == nil {
+		if threadTimestamp := permalink.Query().Get("thread_ts"); threadTimestamp != message.Timestamp {
+			thread = threadTimestamp
+		}
+	}
+
+	return m.openConversation(message.Channel.ID, message.Timestamp, thread)
+}
+
+func searchSummary(t tab) string {
+	return mutedStyle.Render(fmt.Sprintf("page %d of %d · %d results · [ and ] to flip, enter to open", t.searchPage, max(t.searchPages, 1), t.searchTotal))
+}
+
+func (m Model) refreshSearchPager() {
+	t := &m.tabs[searchTab]
+
+	var b strings.Builder
+	t.offsets = t.offsets[:0]
+	line := 0
+	if len(t.searchMessages) > 0 {
+		b.WriteString(lipgloss.NewStyle().Width(m.width-12).Align(lipgloss.Center).Render(searchSummary(*t)) + "\n\n")
+		line += 2
+	}
+
+	for i, message := range t.searchMessages {
+		boxStyle := messageStyle
+		if i == t.searchCursor {
+			boxStyle = selectedMessageStyle
+		}
+
+		if body, ok := t.rendered[searchResultKey(message)]; ok {
+			rendered := boxStyle.Width(m.width - 12).Render(body)
+			t.offsets = append(t.offsets, line)
+			line += lipgloss.Height(rendered) + 1
+			b.WriteString(rendered + "\n\n")
+			continue
+		}
+
+		creatorDisplayName := ""
+		// drawing never asks slack, whoever is in the results was looked up when they came in
+		user := database.QuerySlackUserID(message.User)
+		if user.DisplayName == "" {
+			creatorDisplayName = highlightedStyleBot.Render("@" + user.RealName + " (bot)")
+		} else {
+			creatorDisplayName += highlightedStyle.Render("@" + user.DisplayName)
+		}
+		creatorDisplayName += presenceSuffix(user)
+
+		// anything malformed was dropped when the results came in
+		seconds, _ := strconv.ParseInt(strings.Split(message.Timestamp, ".")[0], 10, 64)
+		tm := time.Unix(seconds, 0)
+		messageString := mutedStyle.Render("\n  ---") + lessMutedStyle.Render("\n  time: ") + evenLessMutedStyle.Render(tm.Format(time.DateTime)) + lessMutedStyle.Render("\n  sender: ") + creatorDisplayName + lessMutedStyle.Render("\n  channel: ") + evenLessMutedStyle.Render(message.Channel.Name) + mutedStyle.Render("\n  ---\n")
+
+		messageString += matchHighlighter.Replace(m.renderMarkdown(message.Text, m.width-18))
+
+		messageString = utils.CachedUserIdParser(messageString, highlightedStyle, highlightedStyleBot)
+		t.rendered[searchResultKey(message)] = messageString
+
+		rendered := boxStyle.Width(m.width - 12).Render(messageString)
+		t.offsets = append(t.offsets, line)
+		line += lipgloss.Height(rendered) + 1
+		b.WriteString(rendered + "\n\n")
+	}
+
+	if len(t.searchMessages) == 0 {
+		b.WriteString(lipgloss.NewStyle().Width(m.width - 12).Align(lipgloss.Center).Render("no message found :("))
+	}
+
+	t.messagePager.SetContent(b.String())
+}
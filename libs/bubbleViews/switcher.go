@@ -0,0 +1,252 @@
+package bubbleViews
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"charming-slack/libs/database"
+)
+
+const switcherResults = 10
+
+var switcherStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#866bef")).Padding(1, 2)
+
+// the ctrl+k overlay for jumping to any conversation or person from anywhere
+type switcher struct {
+	open   bool
+	input  textinput.Model
+	cursor int
+}
+
+// something the switcher can open, people we don't have a dm with yet have no channel
+type switcherItem struct {
+	channel string
+	user    string
+	name    string
+	kind    string
+}
+
+func (m Model) openSwitcher() (Model, tea.Cmd) {
+	m.switcher.open = true
+	m.switcher.cursor = 0
+	m.switcher.input.Reset()
+	m.switcher.input.Placeholder = "jump to a conversation or person"
+	m.switcher.input.Prompt = ""
+
+	return m, m.switcher.input.Focus()
+}
+
+// a person's name without any styling, falling back to their real name
+func plainName(userID string) string {
+	user := database.QuerySlackUserID(userID)
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+	return user.RealName
+}
+
+// every channel, dm and person in the workspace, people already in a dm only show up as the dm
+func (m Model) switcherItems() []switcherItem {
+	items := []switcherItem{}
+	for _, channel := range m.channels {
+		items = append(items, switcherItem{channel: channel.ID, name: channel.Name, kind: "#"})
+	}
+	for _, channel := range m.privateChannels {
+		items = append(items, switcherItem{channel: channel.ID, name: channel.Name, kind: "private"})
+	}
+
+	withDM := map[string]bool{}
+	for _, dm := range m.dms {
+		if dm.IsIM {
+			withDM[dm.User] = true
+			items = append(items, switcherItem{channel: dm.ID, user: dm.User, name: plainName(dm.User), kind: "dm"})
+			continue
+		}
+
+		names := []string{}
+		for _, member := range dm.Members {
+			if member != m.userID {
+				names = append(names, plainName(member))
+			}
+		}
+		items = append(items, switcherItem{channel: dm.ID, name: strings.Join(names, ", "), kind: "group"})
+	}
+
+	// only people from the session's own workspace
+	for _, user := range m.directory {
+		if withDM[user.ID] || user.ID == m.userID || user.IsBot || user.ID == "USLACKBOT" {
+			continue
+		}
+		name := user.Profile.DisplayName
+		if name == "" {
+			name = user.RealName
+		}
+		items = append(items, switcherItem{user: user.ID, name: name, kind: "person"})
+	}
+
+	return items
+}
+
+// how well query matches name as letters in order, -1 when it doesn't. runs of letters
+// and letters at the start of words count for more
+func fuzzyScore(name string, query string) int {
+	runes := []rune(strings.ToLower(name))
+	score := 0
+	last := -2
+	i := 0
+	for _, q := range strings.ToLower(query) {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		for i < len(runes) && runes[i] != q {
+			i++
+		}
+		if i == len(runes) {
+			return -1
+		}
+
+		score++
+		if i == last+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsNumber(runes[i-1]) {
+			score += 3
+		}
+		last = i
+		i++
+	}
+
+	return score
+}
+
+// the best matches, how closely the name matches scaled up by how much and how lately we go there.
+// frecency only grows the score slowly so a close match to somewhere quiet still beats a loose
+// match to somewhere busy, and with nothing typed it's all that's left to go on
+func (m Model) switcherMatches() []switcherItem {
+	query := strings.TrimLeft(m.switcher.input.Value(), "#@")
+	frecency := database.Frecency(m.user)
+
+	type match struct {
+		item     switcherItem
+		score    float64
+		frecency float64
+	}
+	matches := []match{}
+	for _, item := range m.switcherItems() {
+		score := fuzzyScore(item.name, query)
+		if score == -1 {
+			continue
+		}
+		// with nothing typed only show where we've been
+		if query == "" && frecency[item.channel] == 0 {
+			continue
+		}
+		matches = append(matches, match{item, float64(score) * (1 + math.Log1p(frecency[item.channel])), frecency[item.channel]})
+	}
+
+	slices.SortFunc(matches, func(a, b match) int {
+		switch {
+		case a.score != b.score:
+			return cmp.Compare(b.score, a.score)
+		case a.frecency != b.frecency:
+			return cmp.Compare(b.frecency, a.frecency)
+		case len(a.item.name) != len(b.item.name):
+			return len(a.item.name) - len(b.item.name)
+		}
+		return strings.Compare(a.item.name, b.item.name)
+	})
+
+	items := []switcherItem{}
+	for _, match := range matches[:min(len(matches), switcherResults)] {
+		items = append(items, match.item)
+	}
+	return items
+}
+
+func (m Model) updateSwitcher(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+b", "ctrl+k":
+		m.switcher.open = false
+		m.switcher.input.Blur()
+		return m, nil
+	case "up":
+		m.switcher.cursor = max(m.switcher.cursor-1, 0)
+		return m, nil
+	case "down":
+		m.switcher.cursor = max(min(m.switcher.cursor+1, len(m.switcherMatches())-1), 0)
+		return m, nil
+	case "enter":
+		matches := m.switcherMatches()
+		if m.switcher.cursor >= len(matches) {
+			return m, nil
+		}
+		item := matches[m.switcher.cursor]
+		m.switcher.open = false
+		m.switcher.input.Blur()
+
+		if item.channel == "" {
+			m.tabs[m.activeTab].status = "opening the dm..."
+			return m, openDirectMessage(m.slackClient, []string{item.user}, m.userID, m.activeTab)
+		}
+		cmd := m.openConversation(item.channel, "", "")
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.switcher.input, cmd = m.switcher.input.Update(msg)
+	m.switcher.cursor = 0
+	return m, cmd
+}
+
+func switcherView(style lipgloss.Style, m Model) string {
+	matches := m.switcherMatches()
+
+	var b strings.Builder
+	b.WriteString(m.switcher.input.View() + "\n\n")
+	if len(matches) == 0 {
+		if m.switcher.input.Value() == "" {
+			b.WriteString(mutedStyle.Render("start typing, conversations you open often will show up here"))
+		} else {
+			b.WriteString(mutedStyle.Render("nothing matches"))
+		}
+	}
+	for i, item := range matches {
+		name := item.name
+		switch item.kind {
+		case "#":
+			name = "#" + name
+		case "dm", "person":
+			name = "@" + name + presenceSuffix(database.QuerySlackUserID(item.user))
+		}
+		kind := ""
+		if item.kind != "#" {
+			kind = " " + mutedStyle.Render(item.kind)
+		}
+
+		if i == m.switcher.cursor {
+			b.WriteString(selectedItemStyle.Render("> "+name) + kind + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(name) + kind + "\n")
+		}
+	}
+
+	hint := lessMutedStyle.Render("enter to open, esc to close")
+	box := switcherStyle.Width(min(60, m.width-10)).Render(b.String() + "\n" + hint)
+
+	return style.Align(lipgloss.Center, lipgloss.Center).Render(box)
+}
+
+// whatever was opened counts towards ranking it in the switcher
+func (m Model) recordVisit(channel string) {
+	if channel != "" {
+		database.RecordVisit(m.user, channel)
+	}
+}
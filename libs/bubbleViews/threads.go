@@ -0,0 +1,197 @@
+package bubbleViews
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+)
+
+type threadMessageUpdate struct {
+	timestamp string
+	messages  []slack.Message
+	tab       int
+}
+
+func getThread(slackClient *slack.Client, channel string, timestamp string, tab int) tea.Cmd {
+	return func() tea.Msg {
+		messages, _, _, err := slackClient.GetConversationReplies(&slack.GetConversationRepliesParameters{ChannelID: channel, Timestamp: timestamp, Limit: 200})
+		if err != nil {
+			log.Error("error fetching thread", "err", err)
+
+			return errMsg{err}
+		}
+
+		return threadMessageUpdate{timestamp: timestamp, messages: messages, tab: tab}
+	}
+}
+
+func sendThreadReply(channel string, timestamp string, message string, alsoSendToChannel bool, slackClient slack.Client, tab int) tea.Cmd {
+	return func() tea.Msg {
+		options := []slack.MsgOption{slack.MsgOptionText(message, false), slack.MsgOptionTS(timestamp)}
+		if alsoSendToChannel {
+			options = append(options, slack.MsgOptionBroadcast())
+		}
+
+		_, _, err := slackClient.PostMessage(channel, options...)
+		if err != nil {
+			log.Error("error sending reply", "err", err)
+			return errMsg{err}
+		}
+		return sendMessageUpdate{tab: tab, channel: channel, thread: timestamp}
+	}
+}
+
+func (m Model) openThread(tab int, message slack.Message) tea.Cmd {
+	t := &m.tabs[tab]
+
+	// a reply that was broadcast to the channel opens its parent's thread
+	timestamp := message.Timestamp
+	if message.ThreadTimestamp != "" {
+		timestamp = message.ThreadTimestamp
+	}
+
+	t.state = "thread"
+	t.threadTimestamp = timestamp
+	t.threadMessages = []slack.Message{message}
+	t.alsoSendToChannel = false
+	// the channel's draft stays saved and comes back once we leave the thread
+	t.messageInput.SetValue("")
+	m.refreshCompletions(tab)
+	m.refreshThreadPager(tab)
+
+	return getThread(m.slackClient, t.channel, timestamp, tab)
+}
+
+// replies come back oldest first with the parent at the top which is how we show them
+func (m Model) refreshThreadPager(tab int) {
+	t := &m.tabs[tab]
+
+	var b strings.Builder
+	for i, message := range t.threadMessages {
+		var rendered string
+		if i == 0 {
+			// the parent's reply count is already obvious from what's below it
+			rendered = m.renderMessage(withoutReplies(message))
+		} else {
+			body, ok := t.rendered[message.Timestamp]
+			if !ok {
+				body = m.renderMessageBody(message)
+				t.rendered[message.Timestamp] = body
+			}
+			rendered = messageStyle.Width(m.width - 12).Render(body)
+		}
+
+		b.WriteString(rendered + "\n\n")
+		if i == 0 && len(t.threadMessages) > 1 {
+			b.WriteString(mutedStyle.Render("  replies") + "\n\n")
+		}
+	}
+
+	t.threadPager.SetContent(b.String())
+}
+
+func withoutReplies(message slack.Message) slack.Message {
+	message.ReplyCount = 0
+	return message
+}
+
+// adds a reply we were told about live to the open thread and bumps the parent's count
+func (t *tab) applyThreadReply(reply slack.Message) bool {
+	index := slices.IndexFunc(t.messages, func(message slack.Message) bool {
+		return message.Timestamp == reply.ThreadTimestamp
+	})
+	if index != -1 {
+		t.messages[index].ReplyCount++
+		delete(t.rendered, reply.ThreadTimestamp)
+	}
+
+	if t.state != "thread" || t.threadTimestamp != reply.ThreadTimestamp {
+		return false
+	}
+
+	if slices.ContainsFunc(t.threadMessages, func(message slack.Message) bool {
+		return message.Timestamp == reply.Timestamp
+	}) {
+		return false
+	}
+
+	t.threadMessages = append(t.threadMessages, reply)
+	return true
+}
+
+func threadView(m Model) string {
+	t := m.tabs[m.activeTab]
+
+	alsoSend := "[ ]"
+	if t.alsoSendToChannel {
+		alsoSend = "[x]"
+	}
+
+	status := lipgloss.JoinHorizontal(lipgloss.Top,
+		lessMutedStyle.Render("replying in thread  "),
+		evenLessMutedStyle.Render(alsoSend+" also send to channel (ctrl+o)"),
+	)
+
+	return withCompletions(t, pagerOrPreview(m, t.threadPager)) + "\n" + status + "\n" + sendMessageView(m)
+}
+
+func (m Model) updateConversation(tab int, msg tea.Msg) tea.Cmd {
+	t := &m.tabs[tab]
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		if handled, cmd := m.updateMessageSelection(tab, keyMsg); handled {
+			return tea.Batch(cmd, m.loadOlderIfAtTop(tab))
+		}
+		if handled, cmd := m.updateInfoPanel(tab, keyMsg); handled {
+			return cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	switch t.state {
+	case "actions", "profile":
+		return nil
+	case "browse", "createChannel", "pickUsers":
+		return m.updateBrowser(tab, msg)
+	case "editInfo":
+		return m.updateInfoInput(tab, msg)
+	case "react":
+		value := t.reactionInput.Value()
+		t.reactionInput, cmd = t.reactionInput.Update(msg)
+		if t.reactionInput.Value() != value {
+			t.reactionCursor = 0
+		}
+		return cmd
+	}
+
+	switch t.focused {
+	case 0:
+		if t.state == "thread" {
+			t.threadPager, cmd = t.threadPager.Update(msg)
+		} else {
+			t.messagePager, cmd = t.messagePager.Update(msg)
+			m.followViewport(tab)
+			if _, ok := msg.(tea.KeyMsg); ok {
+				cmd = tea.Batch(cmd, m.loadOlderIfAtTop(tab))
+			}
+		}
+	case 1:
+		value := t.messageInput.Value()
+		t.messageInput, cmd = t.messageInput.Update(msg)
+		if t.showPreview && t.messageInput.Value() != value {
+			cmd = tea.Batch(cmd, m.schedulePreview(tab))
+		}
+		if t.messageInput.Value() != value {
+			m.saveDraft(tab)
+		}
+		if _, ok := msg.(tea.KeyMsg); ok {
+			m.refreshCompletions(tab)
+		}
+	}
+
+	return cmd
+}
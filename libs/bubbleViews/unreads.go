@@ -0,0 +1,247 @@
+package bubbleViews
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+	"charming-slack/libs/utils"
+)
+
+// how long to wait between background refreshes of unread counts
+const unreadRefreshInterval = time.Minute
+
+// conversations.info allows about 50 calls a minute, each refresh only takes a slice of the conversations
+const unreadBatchSize = 20
+
+var unreadBadgeStyle = lipgloss.NewStyle().
+	Bold(true).Foreground(lipgloss.Color("#bcbfd3"))
+
+var mentionBadgeStyle = lipgloss.NewStyle().
+	Bold(true).Foreground(lipgloss.Color("#FAFAFA")).Background(lipgloss.Color("#7D56F4")).
+	Padding(0, 1)
+
+type unreadCount struct {
+	unread   int
+	mentions int
+	lastRead string
+}
+
+// a list entry for a conversation along with whatever badges it should show
+type conversationItem struct {
+	name     string
+	unread   int
+	mentions int
+	draft    bool
+}
+
+func (i conversationItem) FilterValue() string { return i.name }
+
+func (i conversationItem) badges() string {
+	badges := ""
+	if i.draft {
+		badges += " " + mutedStyle.Render("✎ draft")
+	}
+	if i.mentions > 0 {
+		badges += " " + mentionBadgeStyle.Render(fmt.Sprintf("@%d", i.mentions))
+	}
+	if i.unread > 0 {
+		badges += " " + unreadBadgeStyle.Render(fmt.Sprintf("(%d)", i.unread))
+	}
+	return badges
+}
+
+type unreadUpdate struct {
+	counts map[string]unreadCount
+	// the background refresh schedules the next one once it's done so they never overlap
+	scheduled bool
+}
+
+type unreadTick struct{}
+
+func scheduleUnreadRefresh() tea.Cmd {
+	return tea.Tick(unreadRefreshInterval, func(time.Time) tea.Msg {
+		return unreadTick{}
+	})
+}
+
+// stops at the first rate limit instead of waiting on it, whatever was missed comes round again later
+func getUnreads(slackClient *slack.Client, channels []slack.Channel, userID string, scheduled bool) tea.Cmd {
+	return func() tea.Msg {
+		counts := map[string]unreadCount{}
+		for _, channel := range channels {
+			info, err := slackClient.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channel.ID})
+			var rateLimited *slack.RateLimitedError
+			if errors.As(err, &rateLimited) {
+				log.Warn("rate limited fetching unread counts", "retry", rateLimited.RetryAfter)
+				break
+			}
+			if err != nil {
+				log.Error("error fetching conversation info", "channel", channel.ID, "err", err)
+				continue
+			}
+
+			count := unreadCount{unread: info.UnreadCountDisplay, lastRead: info.LastRead}
+			if count.unread > 0 {
+				count.mentions = countMentions(slackClient, info, userID)
+			}
+			counts[channel.ID] = count
+		}
+
+		return unreadUpdate{counts, scheduled}
+	}
+}
+
+// the next few conversations to refresh, working through all of them a batch at a time
+func (m *Model) nextUnreadBatch() []slack.Channel {
+	conversations := m.allConversations()
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	batch := []slack.Channel{}
+	for range min(unreadBatchSize, len(conversations)) {
+		m.unreadCursor %= len(conversations)
+		batch = append(batch, conversations[m.unreadCursor])
+		m.unreadCursor++
+	}
+
+	return batch
+}
+
+// the first batch goes out as soon as we know who we are and every list has loaded, the tick takes it from there
+func (m *Model) firstUnreadBatch() tea.Cmd {
+	if m.unreadsStarted || m.userID == "" || m.channels == nil || m.privateChannels == nil || m.dms == nil {
+		return nil
+	}
+	m.unreadsStarted = true
+
+	batch := m.nextUnreadBatch()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return getUnreads(m.slackClient, batch, m.userID, false)
+}
+
+// everything in a dm is meant for you, in channels only messages that mention you or everyone count
+func countMentions(slackClient *slack.Client, info *slack.Channel, userID string) int {
+	if info.IsIM || info.IsMpIM {
+		return info.UnreadCountDisplay
+	}
+
+	history, err := slackClient.GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: info.ID, Oldest: info.LastRead, Limit: 100})
+	if err != nil {
+		log.Error("error fetching unread messages", "channel", info.ID, "err", err)
+		return 0
+	}
+
+	mentions := 0
+	for _, message := range history.Messages {
+		if mentionsUser(message.Text, userID) {
+			mentions++
+		}
+	}
+
+	return mentions
+}
+
+func mentionsUser(text string, userID string) bool {
+	if strings.Contains(text, "<@"+userID) {
+		return true
+	}
+
+	return strings.Contains(text, "<!here") || strings.Contains(text, "<!channel") || strings.Contains(text, "<!everyone")
+}
+
+func markRead(slackClient *slack.Client, channel string, timestamp string) tea.Cmd {
+	return func() tea.Msg {
+		if err := slackClient.MarkConversation(channel, timestamp); err != nil {
+			log.Error("error marking conversation read", "channel", channel, "err", err)
+		}
+
+		return nil
+	}
+}
+
+// clears the badges straight away instead of waiting for the next refresh
+func (m *Model) markConversationRead(channel string, timestamp string) tea.Cmd {
+	m.unreads[channel] = unreadCount{lastRead: timestamp}
+	m.refreshConversationLists()
+
+	return markRead(m.slackClient, channel, timestamp)
+}
+
+// whether a tab is currently showing the conversation
+func (m Model) isOpen(channel string) bool {
+	for _, t := range m.tabs {
+		if t.channel == channel && t.state != "select" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m Model) allConversations() []slack.Channel {
+	conversations := append([]slack.Channel{}, m.channels...)
+	conversations = append(conversations, m.privateChannels...)
+	return append(conversations, m.dms...)
+}
+
+// rebuilds the lists so their badges match the latest counts, leaving the
+// loading placeholder alone on any list that hasn't been fetched yet
+func (m *Model) refreshConversationLists() {
+	if m.channels != nil {
+		m.channelList.SetItems(m.conversationItems(m.channels, nil))
+	}
+	if m.privateChannels != nil {
+		m.privateChannelList.SetItems(m.conversationItems(m.privateChannels, nil))
+	}
+	if m.dms != nil {
+		m.dmList.SetItems(m.conversationItems(m.dms, m.dmNames))
+	}
+}
+
+// cuts names down to the list's width, badges are left out of the count so they always show
+func clampItems(items []list.Item, width int) []list.Item {
+	clamped := []list.Item{}
+	for _, listItem := range items {
+		switch i := listItem.(type) {
+		case conversationItem:
+			i.name = utils.ClampString(i.name, width)
+			clamped = append(clamped, i)
+		default:
+			clamped = append(clamped, item(utils.ClampString(fmt.Sprintf("%v", i), width)))
+		}
+	}
+
+	return clamped
+}
+
+func (m Model) conversationItems(channels []slack.Channel, names []string) []list.Item {
+	items := []list.Item{}
+	for i, channel := range channels {
+		name := channel.Name
+		if i < len(names) {
+			name = names[i]
+		}
+
+		if channel.IsIM {
+			name += presenceSuffix(database.QuerySlackUserID(channel.User))
+		}
+
+		count := m.unreads[channel.ID]
+		items = append(items, conversationItem{name: name, unread: count.unread, mentions: count.mentions, draft: m.hasDraft(channel.ID)})
+	}
+
+	return items
+}
@@ -2,11 +2,13 @@ package database
 
 import (
 	"encoding/json"
+	"math"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
 	"github.com/slack-go/slack"
 )
 
@@ -15,12 +17,16 @@ var DB = Database{
 	ApplicationData: map[string]UserData{},
 	SlackMap:        map[string]SlackUserMap{},
 	EmojiMap:        map[string]string{},
+	Drafts:          map[string]map[string]string{},
+	Frecency:        map[string]map[string]Visits{},
 }
 
 var (
 	SlackMapMutex        = sync.RWMutex{}
 	ApplicationDataMutex = sync.RWMutex{}
 	EmojiMutex           = sync.RWMutex{}
+	DraftsMutex          = sync.RWMutex{}
+	FrecencyMutex        = sync.RWMutex{}
 )
 
 type UserData struct {
@@ -33,12 +39,65 @@ type UserData struct {
 type SlackUserMap struct {
 	RealName    string
 	DisplayName string
+	// the custom status they've set, the expiration is a unix time and zero when it never clears
+	StatusText       string
+	StatusEmoji      string
+	StatusExpiration int64
+	// active or away, empty until we've asked. it goes stale too quickly to be worth saving
+	Presence string `json:"-"`
+	// the rest of their profile for the profile card, pronouns only get filled in once it's been opened
+	Title    string
+	Pronouns string
+	Email    string
+	TZ       string
+	TZOffset int
+	Avatar   string
+}
+
+func newSlackUserMap(identity *slack.User) SlackUserMap {
+	return SlackUserMap{
+		RealName:         identity.Profile.RealNameNormalized,
+		DisplayName:      identity.Profile.DisplayNameNormalized,
+		StatusText:       identity.Profile.StatusText,
+		StatusEmoji:      identity.Profile.StatusEmoji,
+		StatusExpiration: int64(identity.Profile.StatusExpiration),
+		Title:            identity.Profile.Title,
+		Email:            identity.Profile.Email,
+		TZ:               identity.TZ,
+		TZOffset:         identity.TZOffset,
+		Avatar:           identity.Profile.Image192,
+	}
+}
+
+// the custom status if there is one and it hasn't run out yet
+func (u SlackUserMap) Status() (string, string) {
+	if u.StatusExpiration != 0 && time.Unix(u.StatusExpiration, 0).Before(time.Now()) {
+		return "", ""
+	}
+	return u.StatusEmoji, u.StatusText
 }
 
 type Database struct {
 	ApplicationData map[string]UserData
 	SlackMap        map[string]SlackUserMap
 	EmojiMap        map[string]string
+	// unsent messages keyed by the ssh user and then the conversation they were typed in
+	Drafts map[string]map[string]string
+	// how often and how lately each ssh user has opened each conversation
+	Frecency map[string]map[string]Visits
+}
+
+// a score that grows with every visit and halves every frecencyHalfLife without one
+type Visits struct {
+	Score float64
+	Last  int64
+}
+
+const frecencyHalfLife = 3 * 24 * time.Hour
+
+func (v Visits) At(now time.Time) float64 {
+	elapsed := now.Sub(time.Unix(v.Last, 0))
+	return v.Score * math.Pow(0.5, float64(elapsed)/float64(frecencyHalfLife))
 }
 
 func SetUserData(user string, slackToken string, refreshToken string, realName string) {
@@ -52,6 +111,27 @@ func SetUserData(user string, slackToken string, refreshToken string, realName s
 	ApplicationDataMutex.Unlock()
 }
 
+// looks up the user and checks the key they connected with is the one they signed up with
+func AuthenticateUser(user string, publicKey ssh.PublicKey) (UserData, bool) {
+	if publicKey == nil {
+		return UserData{}, false
+	}
+
+	ApplicationDataMutex.RLock()
+	userData, ok := DB.ApplicationData[user]
+	ApplicationDataMutex.RUnlock()
+	if !ok {
+		return UserData{}, false
+	}
+
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(userData.PublicKey))
+	if err != nil || !ssh.KeysEqual(publicKey, parsed) {
+		return UserData{}, false
+	}
+
+	return userData, true
+}
+
 func QuerySlackUserID(userid string) SlackUserMap {
 	SlackMapMutex.Lock()
 	user := DB.SlackMap[userid]
@@ -59,6 +139,14 @@ func QuerySlackUserID(userid string) SlackUserMap {
 	return user
 }
 
+// the same as QuerySlackUserID but says whether the user was cached at all
+func CachedSlackUser(userid string) (SlackUserMap, bool) {
+	SlackMapMutex.Lock()
+	user, ok := DB.SlackMap[userid]
+	SlackMapMutex.Unlock()
+	return user, ok
+}
+
 func AddSlackUser(userid string, realName string, displayName string) {
 	SlackMapMutex.Lock()
 	DB.SlackMap[userid] = SlackUserMap{
@@ -68,6 +156,103 @@ func AddSlackUser(userid string, realName string, displayName string) {
 	SlackMapMutex.Unlock()
 }
 
+// keeps a cached user's status up to date, users we haven't cached yet are skipped
+func SetSlackUserStatus(userid string, text string, emoji string, expiration int64) {
+	SlackMapMutex.Lock()
+	if user, ok := DB.SlackMap[userid]; ok {
+		user.StatusText = text
+		user.StatusEmoji = emoji
+		user.StatusExpiration = expiration
+		DB.SlackMap[userid] = user
+	}
+	SlackMapMutex.Unlock()
+}
+
+// fetches a user again even if they're cached, what we only learn from elsewhere is kept
+func RefreshSlackUser(userid string, slackClient slack.Client) (SlackUserMap, error) {
+	identity, err := slackClient.GetUserInfo(userid)
+	if err != nil {
+		return SlackUserMap{}, err
+	}
+
+	user := newSlackUserMap(identity)
+	SlackMapMutex.Lock()
+	user.Presence = DB.SlackMap[userid].Presence
+	user.Pronouns = DB.SlackMap[userid].Pronouns
+	DB.SlackMap[userid] = user
+	SlackMapMutex.Unlock()
+
+	return user, nil
+}
+
+func SetSlackUserPronouns(userid string, pronouns string) {
+	SlackMapMutex.Lock()
+	if user, ok := DB.SlackMap[userid]; ok {
+		user.Pronouns = pronouns
+		DB.SlackMap[userid] = user
+	}
+	SlackMapMutex.Unlock()
+}
+
+func SetSlackUserPresence(userid string, presence string) {
+	SlackMapMutex.Lock()
+	if user, ok := DB.SlackMap[userid]; ok {
+		user.Presence = presence
+		DB.SlackMap[userid] = user
+	}
+	SlackMapMutex.Unlock()
+}
+
+// keeps what's been typed in a conversation, an empty draft removes it
+func SetDraft(user string, channel string, text string) {
+	DraftsMutex.Lock()
+	if text == "" {
+		delete(DB.Drafts[user], channel)
+	} else {
+		if DB.Drafts == nil {
+			DB.Drafts = map[string]map[string]string{}
+		}
+		if DB.Drafts[user] == nil {
+			DB.Drafts[user] = map[string]string{}
+		}
+		DB.Drafts[user][channel] = text
+	}
+	DraftsMutex.Unlock()
+}
+
+func GetDraft(user string, channel string) string {
+	DraftsMutex.RLock()
+	draft := DB.Drafts[user][channel]
+	DraftsMutex.RUnlock()
+	return draft
+}
+
+func RecordVisit(user string, conversation string) {
+	now := time.Now()
+	FrecencyMutex.Lock()
+	if DB.Frecency == nil {
+		DB.Frecency = map[string]map[string]Visits{}
+	}
+	if DB.Frecency[user] == nil {
+		DB.Frecency[user] = map[string]Visits{}
+	}
+	visits := DB.Frecency[user][conversation]
+	DB.Frecency[user][conversation] = Visits{Score: visits.At(now) + 1, Last: now.Unix()}
+	FrecencyMutex.Unlock()
+}
+
+// every conversation the user has opened and how highly it ranks right now
+func Frecency(user string) map[string]float64 {
+	now := time.Now()
+	FrecencyMutex.RLock()
+	scores := make(map[string]float64, len(DB.Frecency[user]))
+	for conversation, visits := range DB.Frecency[user] {
+		scores[conversation] = visits.At(now)
+	}
+	FrecencyMutex.RUnlock()
+	return scores
+}
+
 func AddEmoji(name string, url string) {
 	EmojiMutex.Lock()
 	DB.EmojiMap[name] = url
@@ -81,6 +266,16 @@ func QueryEmoji(name string) string {
 	return emoji
 }
 
+func EmojiNames() []string {
+	EmojiMutex.Lock()
+	names := make([]string, 0, len(DB.EmojiMap))
+	for name := range DB.EmojiMap {
+		names = append(names, name)
+	}
+	EmojiMutex.Unlock()
+	return names
+}
+
 func EmojiCount() int {
 	EmojiMutex.Lock()
 	count := len(DB.EmojiMap)
@@ -111,10 +306,7 @@ func GetUserOrCreate(userid string, slackClient slack.Client) SlackUserMap {
 				}
 			}
 
-			user = SlackUserMap{
-				RealName:    identity.Profile.RealNameNormalized,
-				DisplayName: identity.Profile.DisplayNameNormalized,
-			}
+			user = newSlackUserMap(identity)
 		}
 
 		SlackMapMutex.Lock()
@@ -132,10 +324,14 @@ func GetUserOrCreate(userid string, slackClient slack.Client) SlackUserMap {
 func SaveUserData() {
 	SlackMapMutex.Lock()
 	ApplicationDataMutex.Lock()
+	DraftsMutex.Lock()
+	FrecencyMutex.Lock()
 	// save the database to a file, if it doesn't exist, create it
 	jsonData, err := json.Marshal(DB)
 	SlackMapMutex.Unlock()
 	ApplicationDataMutex.Unlock()
+	DraftsMutex.Unlock()
+	FrecencyMutex.Unlock()
 	if err != nil {
 		log.Error("Could not marshal users data to JSON", "error", err)
 		return
@@ -157,11 +353,15 @@ func LoadUserData() {
 
 	SlackMapMutex.Lock()
 	ApplicationDataMutex.Lock()
+	DraftsMutex.Lock()
+	FrecencyMutex.Lock()
 
 	err = json.Unmarshal(jsonData, &DB)
 
 	SlackMapMutex.Unlock()
 	ApplicationDataMutex.Unlock()
+	DraftsMutex.Unlock()
+	FrecencyMutex.Unlock()
 
 	if err != nil {
 		log.Error("Could not unmarshal users data from JSON", "error", err)
@@ -0,0 +1,348 @@
+package database
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+)
+
+// a copy of a message someone has fetched, kept so it can be searched without slack
+type IndexedMessage struct {
+	Channel         string
+	Timestamp       string
+	ThreadTimestamp string
+	User            string
+	Text            string
+	HasFile         bool
+}
+
+func (message IndexedMessage) Time() time.Time {
+	seconds, _ := strconv.ParseInt(strings.Split(message.Timestamp, ".")[0], 10, 64)
+	return time.Unix(seconds, 0)
+}
+
+// an inverted index of one user's messages, only the messages are saved and the rest is rebuilt on load
+type messageIndex struct {
+	messages map[string]IndexedMessage
+	// every word mapped to the messages it's in
+	postings map[string]map[string]bool
+	// the words in order, kept for prefix lookups and rebuilt when new words show up
+	terms      []string
+	termsDirty bool
+}
+
+// oldest messages are dropped past this so the index doesn't grow forever
+const maxIndexedMessages = 50000
+
+// every ssh user's index, they only ever search messages they've been able to see
+var indexes = map[string]*messageIndex{}
+
+var indexMutex = sync.RWMutex{}
+
+func newMessageIndex() *messageIndex {
+	return &messageIndex{messages: map[string]IndexedMessage{}, postings: map[string]map[string]bool{}}
+}
+
+func indexKey(channel string, timestamp string) string {
+	return channel + "/" + timestamp
+}
+
+// lowercase words and numbers, everything else separates them. slack's <@U123> style
+// tokens come out as their ids which is enough to find mentions
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func (index *messageIndex) add(message IndexedMessage) {
+	key := indexKey(message.Channel, message.Timestamp)
+	if existing, ok := index.messages[key]; ok {
+		if existing == message {
+			return
+		}
+		index.remove(key)
+	}
+
+	index.messages[key] = message
+	for _, term := range Tokenize(message.Text) {
+		if index.postings[term] == nil {
+			index.postings[term] = map[string]bool{}
+			index.termsDirty = true
+		}
+		index.postings[term][key] = true
+	}
+}
+
+func (index *messageIndex) remove(key string) {
+	message, ok := index.messages[key]
+	if !ok {
+		return
+	}
+
+	delete(index.messages, key)
+	for _, term := range Tokenize(message.Text) {
+		delete(index.postings[term], key)
+		if len(index.postings[term]) == 0 {
+			delete(index.postings, term)
+			index.termsDirty = true
+		}
+	}
+}
+
+// drops the oldest tenth once there are too many so this doesn't run on every message
+func (index *messageIndex) trim() {
+	if len(index.messages) <= maxIndexedMessages {
+		return
+	}
+
+	keys := make([]string, 0, len(index.messages))
+	for key := range index.messages {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return index.messages[keys[i]].Timestamp < index.messages[keys[j]].Timestamp
+	})
+	for _, key := range keys[:len(keys)-maxIndexedMessages*9/10] {
+		index.remove(key)
+	}
+}
+
+// every word starting with prefix, from the sorted list of words
+func (index *messageIndex) prefixTerms(prefix string) []string {
+	if index.termsDirty || index.terms == nil {
+		index.terms = make([]string, 0, len(index.postings))
+		for term := range index.postings {
+			index.terms = append(index.terms, term)
+		}
+		sort.Strings(index.terms)
+		index.termsDirty = false
+	}
+
+	start := sort.SearchStrings(index.terms, prefix)
+	end := start
+	for end < len(index.terms) && strings.HasPrefix(index.terms[end], prefix) {
+		end++
+	}
+
+	return index.terms[start:end]
+}
+
+// copies the parts of the messages we search on into the user's index
+func IndexMessages(user string, channel string, messages []slack.Message) {
+	indexMutex.Lock()
+	index := indexes[user]
+	if index == nil {
+		index = newMessageIndex()
+		indexes[user] = index
+	}
+	for _, message := range messages {
+		if message.Text == "" && len(message.Files) == 0 {
+			continue
+		}
+		index.add(IndexedMessage{
+			Channel:         channel,
+			Timestamp:       message.Timestamp,
+			ThreadTimestamp: message.ThreadTimestamp,
+			User:            message.User,
+			Text:            message.Text,
+			HasFile:         len(message.Files) > 0,
+		})
+	}
+	index.trim()
+	indexMutex.Unlock()
+}
+
+func UnindexMessage(user string, channel string, timestamp string) {
+	indexMutex.Lock()
+	if index := indexes[user]; index != nil {
+		index.remove(indexKey(channel, timestamp))
+	}
+	indexMutex.Unlock()
+}
+
+// what to look for in the index, the same filters slack's search has
+type IndexQuery struct {
+	Text    string
+	Channel string
+	User    string
+	HasLink bool
+	HasFile bool
+	After   time.Time
+	Before  time.Time
+	// newest first instead of most matches first
+	ByTime bool
+}
+
+// quoted parts of the text are phrases, everything else is single words
+func parseQuery(text string) (words []string, phrases [][]string) {
+	for i, part := range strings.Split(text, "\"") {
+		if i%2 == 1 {
+			if phrase := Tokenize(part); len(phrase) > 0 {
+				phrases = append(phrases, phrase)
+			}
+			continue
+		}
+		words = append(words, Tokenize(part)...)
+	}
+
+	return words, phrases
+}
+
+func containsPhrase(tokens []string, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		if slices.Equal(tokens[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+type IndexResult struct {
+	Message IndexedMessage
+	// the words in the message that matched, for highlighting
+	Matches []string
+}
+
+// words match as prefixes so results show up while the last one is still being typed,
+// quoted phrases have to appear whole and in order
+func SearchIndex(user string, query IndexQuery) []IndexResult {
+	indexMutex.Lock()
+	defer indexMutex.Unlock()
+
+	index := indexes[user]
+	if index == nil {
+		return nil
+	}
+
+	words, phrases := parseQuery(query.Text)
+	for _, phrase := range phrases {
+		words = append(words, phrase...)
+	}
+
+	// each word narrows down the messages to those with any word it's a prefix of
+	var candidates map[string]bool
+	for _, word := range words {
+		matching := map[string]bool{}
+		for _, term := range index.prefixTerms(word) {
+			for key := range index.postings[term] {
+				if candidates == nil || candidates[key] {
+					matching[key] = true
+				}
+			}
+		}
+		candidates = matching
+	}
+	if candidates == nil {
+		// only filters, so every message is a candidate
+		candidates = map[string]bool{}
+		for key := range index.messages {
+			candidates[key] = true
+		}
+	}
+
+	results := []IndexResult{}
+	scores := map[string]int{}
+	for key := range candidates {
+		message := index.messages[key]
+		if query.Channel != "" && message.Channel != query.Channel {
+			continue
+		}
+		if query.User != "" && message.User != query.User {
+			continue
+		}
+		if query.HasLink && !strings.Contains(message.Text, "<http") {
+			continue
+		}
+		if query.HasFile && !message.HasFile {
+			continue
+		}
+		if !query.After.IsZero() && !message.Time().After(query.After) {
+			continue
+		}
+		if !query.Before.IsZero() && !message.Time().Before(query.Before) {
+			continue
+		}
+
+		tokens := Tokenize(message.Text)
+		if !slices.ContainsFunc(phrases, func(phrase []string) bool { return !containsPhrase(tokens, phrase) }) {
+			matches := []string{}
+			for _, token := range tokens {
+				if slices.ContainsFunc(words, func(word string) bool { return strings.HasPrefix(token, word) }) {
+					matches = append(matches, token)
+				}
+			}
+			scores[key] = len(matches)
+			results = append(results, IndexResult{message, matches})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i].Message, results[j].Message
+		if !query.ByTime {
+			scoreA, scoreB := scores[indexKey(a.Channel, a.Timestamp)], scores[indexKey(b.Channel, b.Timestamp)]
+			if scoreA != scoreB {
+				return scoreA > scoreB
+			}
+		}
+		return a.Timestamp > b.Timestamp
+	})
+
+	return results
+}
+
+func SaveIndex() {
+	indexMutex.RLock()
+	saved := map[string]map[string]IndexedMessage{}
+	for user, index := range indexes {
+		saved[user] = index.messages
+	}
+	jsonData, err := json.Marshal(saved)
+	indexMutex.RUnlock()
+	if err != nil {
+		log.Error("Could not marshal message index to JSON", "error", err)
+		return
+	}
+
+	// it's everyone's messages so only we get to read it, the chmod catches files made before that
+	err = os.WriteFile("./.ssh/index.json", jsonData, 0600)
+	if err == nil {
+		err = os.Chmod("./.ssh/index.json", 0600)
+	}
+	if err != nil {
+		log.Error("Could not create message index file", "error", err)
+	}
+}
+
+func LoadIndex() {
+	jsonData, err := os.ReadFile("./.ssh/index.json")
+	if err != nil {
+		log.Error("Could not read message index file", "error", err)
+		return
+	}
+
+	saved := map[string]map[string]IndexedMessage{}
+	if err := json.Unmarshal(jsonData, &saved); err != nil {
+		log.Error("Could not unmarshal message index from JSON", "error", err)
+		return
+	}
+
+	indexMutex.Lock()
+	for user, messages := range saved {
+		index := newMessageIndex()
+		for _, message := range messages {
+			index.add(message)
+		}
+		indexes[user] = index
+	}
+	indexMutex.Unlock()
+}
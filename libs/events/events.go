@@ -0,0 +1,467 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+type Kind int
+
+const (
+	MessageNew Kind = iota
+	MessageChanged
+	MessageDeleted
+	// a reply posted inside a thread, Message.ThreadTimestamp is the parent
+	ThreadReply
+	// reactions only carry the message timestamp along with Reaction and User
+	ReactionAdded
+	ReactionRemoved
+)
+
+// an event is a single change to a conversation that an open tab cares about
+type Event struct {
+	Kind     Kind
+	Channel  string
+	Message  slack.Message
+	Reaction string
+	User     string
+}
+
+// a source streams events for the channels it has been subscribed to
+// the seed is the history the caller already has so it isn't sent again
+type Source interface {
+	Subscribe(channel string, seed []slack.Message)
+	Unsubscribe(channel string)
+	Events() <-chan Event
+	Close()
+}
+
+// how many of the most recent messages the poller diffs each tick
+const windowSize = 50
+
+type HistoryClient interface {
+	GetConversationHistory(params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error)
+}
+
+// the poller is the fallback source, it re-reads the newest slice of history
+// with conversations.history and diffs it against what it saw last time
+type Poller struct {
+	client   HistoryClient
+	interval time.Duration
+	events   chan Event
+	mutex    sync.Mutex
+	cancels  map[string]context.CancelFunc
+	wg       sync.WaitGroup
+	closed   bool
+}
+
+func NewPoller(client HistoryClient, interval time.Duration) *Poller {
+	return &Poller{
+		client:   client,
+		interval: interval,
+		events:   make(chan Event, 64),
+		cancels:  map[string]context.CancelFunc{},
+	}
+}
+
+func (p *Poller) Events() <-chan Event {
+	return p.events
+}
+
+func (p *Poller) Subscribe(channel string, seed []slack.Message) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	if cancel, ok := p.cancels[channel]; ok {
+		cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancels[channel] = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.poll(ctx, channel, seed)
+	}()
+}
+
+func (p *Poller) Unsubscribe(channel string) {
+	p.mutex.Lock()
+	if cancel, ok := p.cancels[channel]; ok {
+		cancel()
+		delete(p.cancels, channel)
+	}
+	p.mutex.Unlock()
+}
+
+func (p *Poller) Close() {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return
+	}
+	p.closed = true
+	for channel, cancel := range p.cancels {
+		cancel()
+		delete(p.cancels, channel)
+	}
+	p.mutex.Unlock()
+
+	// wait for every poll loop to stop before closing so nothing sends on a closed channel
+	p.wg.Wait()
+	close(p.events)
+}
+
+func (p *Poller) poll(ctx context.Context, channel string, seed []slack.Message) {
+	window := map[string]slack.Message{}
+	for _, message := range seed {
+		window[message.Timestamp] = message
+	}
+	window = trimWindow(window)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		oldest := oldestTimestamp(window)
+		history, err := p.client.GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: channel, Oldest: oldest, Inclusive: true, Limit: 100})
+		if err != nil {
+			log.Error("error polling messages", "channel", channel, "err", err)
+			continue
+		}
+
+		fetched := map[string]slack.Message{}
+		for _, message := range history.Messages {
+			fetched[message.Timestamp] = message
+		}
+
+		var changes []Event
+		// history comes back newest first so walk it backwards to emit in the order things happened
+		for i := len(history.Messages) - 1; i >= 0; i-- {
+			message := history.Messages[i]
+			previous, ok := window[message.Timestamp]
+			switch {
+			case !ok:
+				changes = append(changes, Event{Kind: MessageNew, Channel: channel, Message: message})
+			case changed(previous, message):
+				changes = append(changes, Event{Kind: MessageChanged, Channel: channel, Message: message})
+			}
+		}
+
+		// if slack cut the page short we can't tell a deleted message from one that didn't fit
+		if !history.HasMore {
+			for timestamp, message := range window {
+				if _, ok := fetched[timestamp]; !ok {
+					changes = append(changes, Event{Kind: MessageDeleted, Channel: channel, Message: message})
+				}
+			}
+		}
+
+		for _, change := range changes {
+			select {
+			case p.events <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		window = trimWindow(fetched)
+	}
+}
+
+// whether anything we render differs between two copies of a message
+func changed(previous, message slack.Message) bool {
+	return editedTimestamp(previous) != editedTimestamp(message) ||
+		previous.Text != message.Text ||
+		previous.ReplyCount != message.ReplyCount ||
+		// link unfurls show up as attachments a moment after the message is posted
+		len(previous.Attachments) != len(message.Attachments) ||
+		len(previous.Files) != len(message.Files) ||
+		reactionsKey(previous) != reactionsKey(message)
+}
+
+func reactionsKey(message slack.Message) string {
+	var b strings.Builder
+	for _, reaction := range message.Reactions {
+		b.WriteString(reaction.Name + ":" + strings.Join(reaction.Users, ",") + ";")
+	}
+	return b.String()
+}
+
+func editedTimestamp(message slack.Message) string {
+	if message.Edited == nil {
+		return ""
+	}
+	return message.Edited.Timestamp
+}
+
+func oldestTimestamp(window map[string]slack.Message) string {
+	oldest := ""
+	for timestamp := range window {
+		if oldest == "" || compareTimestamps(timestamp, oldest) < 0 {
+			oldest = timestamp
+		}
+	}
+	return oldest
+}
+
+// drops the oldest messages until only windowSize are left
+func trimWindow(window map[string]slack.Message) map[string]slack.Message {
+	for len(window) > windowSize {
+		delete(window, oldestTimestamp(window))
+	}
+	return window
+}
+
+// slack timestamps are "seconds.micros" with a fixed width fraction so comparing
+// the seconds numerically and then the rest as a string is enough
+func compareTimestamps(a, b string) int {
+	aSeconds, aFraction, _ := strings.Cut(a, ".")
+	bSeconds, bFraction, _ := strings.Cut(b, ".")
+	if len(aSeconds) != len(bSeconds) {
+		return len(aSeconds) - len(bSeconds)
+	}
+	return strings.Compare(aSeconds+aFraction, bSeconds+bFraction)
+}
+
+// the hub fans events from a single shared connection out to every session
+// watching the channel, it's also what you publish into to fake a source locally
+type Hub struct {
+	mutex   sync.RWMutex
+	streams map[*hubStream]struct{}
+	live    bool
+}
+
+var DefaultHub = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{streams: map[*hubStream]struct{}{}}
+}
+
+// whether something is actually publishing into the hub right now
+func (h *Hub) Live() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.live
+}
+
+func (h *Hub) SetLive(live bool) {
+	h.mutex.Lock()
+	h.live = live
+	h.mutex.Unlock()
+}
+
+// marks the hub as down and closes every stream so the sessions on it go back to polling
+func (h *Hub) Reset() {
+	h.mutex.Lock()
+	h.live = false
+	streams := h.streams
+	h.streams = map[*hubStream]struct{}{}
+	h.mutex.Unlock()
+
+	for stream := range streams {
+		stream.close()
+	}
+}
+
+func (h *Hub) Publish(event Event) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for stream := range h.streams {
+		stream.send(event)
+	}
+}
+
+func (h *Hub) NewSource() Source {
+	stream := &hubStream{
+		hub:      h,
+		channels: map[string]bool{},
+		events:   make(chan Event, 64),
+	}
+
+	h.mutex.Lock()
+	h.streams[stream] = struct{}{}
+	h.mutex.Unlock()
+
+	return stream
+}
+
+type hubStream struct {
+	hub      *Hub
+	mutex    sync.Mutex
+	channels map[string]bool
+	events   chan Event
+	closed   bool
+}
+
+func (s *hubStream) Events() <-chan Event {
+	return s.events
+}
+
+func (s *hubStream) Subscribe(channel string, _ []slack.Message) {
+	s.mutex.Lock()
+	s.channels[channel] = true
+	s.mutex.Unlock()
+}
+
+func (s *hubStream) Unsubscribe(channel string) {
+	s.mutex.Lock()
+	delete(s.channels, channel)
+	s.mutex.Unlock()
+}
+
+func (s *hubStream) send(event Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed || !s.channels[event.Channel] {
+		return
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		log.Warn("dropping event for slow session", "channel", event.Channel)
+	}
+}
+
+func (s *hubStream) Close() {
+	s.hub.mutex.Lock()
+	delete(s.hub.streams, s)
+	s.hub.mutex.Unlock()
+
+	s.close()
+}
+
+func (s *hubStream) close() {
+	s.mutex.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mutex.Unlock()
+}
+
+// connects to slack over socket mode with an app level token and publishes every
+// message event it gets into the hub until the context is cancelled
+func RunSocketMode(ctx context.Context, hub *Hub, appToken string) error {
+	client := socketmode.New(slack.New("", slack.OptionAppLevelToken(appToken)))
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt := <-client.Events:
+				switch evt.Type {
+				case socketmode.EventTypeConnected:
+					log.Info("socket mode connected")
+					hub.SetLive(true)
+				case socketmode.EventTypeConnectionError, socketmode.EventTypeInvalidAuth:
+					log.Error("socket mode connection error", "data", evt.Data)
+					hub.Reset()
+				case socketmode.EventTypeDisconnect:
+					log.Warn("socket mode disconnected")
+					hub.Reset()
+				case socketmode.EventTypeEventsAPI:
+					client.Ack(*evt.Request)
+
+					eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
+					if !ok {
+						continue
+					}
+					switch inner := eventsAPIEvent.InnerEvent.Data.(type) {
+					case *slackevents.MessageEvent:
+						if event, ok := fromMessageEvent(inner); ok {
+							hub.Publish(event)
+						}
+					case *slackevents.ReactionAddedEvent:
+						hub.Publish(Event{Kind: ReactionAdded, Channel: inner.Item.Channel, Message: slack.Message{Msg: slack.Msg{Timestamp: inner.Item.Timestamp}}, Reaction: inner.Reaction, User: inner.User})
+					case *slackevents.ReactionRemovedEvent:
+						hub.Publish(Event{Kind: ReactionRemoved, Channel: inner.Item.Channel, Message: slack.Message{Msg: slack.Msg{Timestamp: inner.Item.Timestamp}}, Reaction: inner.Reaction, User: inner.User})
+					}
+				}
+			}
+		}
+	}()
+
+	defer hub.Reset()
+	return client.RunContext(ctx)
+}
+
+func fromMessageEvent(ev *slackevents.MessageEvent) (Event, bool) {
+	switch ev.SubType {
+	case "message_changed":
+		if ev.Message == nil || ev.Message.ThreadTimeStamp != "" && ev.Message.ThreadTimeStamp != ev.Message.TimeStamp {
+			return Event{}, false
+		}
+		return Event{Kind: MessageChanged, Channel: ev.Channel, Message: toMessage(ev.Message)}, true
+	case "message_deleted":
+		message := slack.Message{}
+		if ev.PreviousMessage != nil {
+			message = toMessage(ev.PreviousMessage)
+		}
+		message.Timestamp = ev.DeletedTimeStamp
+		return Event{Kind: MessageDeleted, Channel: ev.Channel, Message: message}, true
+	case "", "thread_broadcast", "me_message", "file_share", "bot_message":
+		// replies live in their thread, only the parent and broadcasts belong in the channel
+		if ev.ThreadTimeStamp != "" && ev.ThreadTimeStamp != ev.TimeStamp && ev.SubType != "thread_broadcast" {
+			return Event{Kind: ThreadReply, Channel: ev.Channel, Message: toMessage(ev)}, true
+		}
+		return Event{Kind: MessageNew, Channel: ev.Channel, Message: toMessage(ev)}, true
+	}
+
+	// everything else, like message_replied or channel_topic, is bookkeeping with nothing to show
+	return Event{}, false
+}
+
+func toMessage(ev *slackevents.MessageEvent) slack.Message {
+	message := slack.Message{Msg: slack.Msg{
+		Channel:         ev.Channel,
+		User:            ev.User,
+		Text:            ev.Text,
+		Timestamp:       ev.TimeStamp,
+		ThreadTimestamp: ev.ThreadTimeStamp,
+		SubType:         ev.SubType,
+		BotID:           ev.BotID,
+		Username:        ev.Username,
+		Blocks:          ev.Blocks,
+		Attachments:     ev.Attachments,
+	}}
+	if ev.Edited != nil {
+		message.Edited = &slack.Edited{User: ev.Edited.User, Timestamp: ev.Edited.TimeStamp}
+	}
+	// only the bits of a shared file we actually show
+	for _, file := range ev.Files {
+		message.Files = append(message.Files, slack.File{
+			ID:         file.ID,
+			Name:       file.Name,
+			Title:      file.Title,
+			Mimetype:   file.Mimetype,
+			Filetype:   file.Filetype,
+			PrettyType: file.PrettyType,
+			Size:       file.Size,
+			Permalink:  file.Permalink,
+		})
+	}
+	return message
+}
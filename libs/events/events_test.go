@@ -0,0 +1,118 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func message(timestamp string, text string) slack.Message {
+	return slack.Message{Msg: slack.Msg{Timestamp: timestamp, Text: text}}
+}
+
+func receive(t *testing.T, source Source) Event {
+	t.Helper()
+	select {
+	case event, ok := <-source.Events():
+		if !ok {
+			t.Fatal("source closed before an event arrived")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return Event{}
+}
+
+func TestHubOnlySendsSubscribedChannels(t *testing.T) {
+	hub := NewHub()
+	source := hub.NewSource()
+	defer source.Close()
+
+	source.Subscribe("C1", nil)
+	hub.Publish(Event{Kind: MessageNew, Channel: "C2", Message: message("1.000001", "elsewhere")})
+	hub.Publish(Event{Kind: MessageNew, Channel: "C1", Message: message("1.000002", "here")})
+
+	if event := receive(t, source); event.Channel != "C1" || event.Message.Text != "here" {
+		t.Fatalf("got %+v, want the C1 message", event)
+	}
+
+	source.Unsubscribe("C1")
+	hub.Publish(Event{Kind: MessageNew, Channel: "C1", Message: message("1.000003", "gone")})
+	select {
+	case event := <-source.Events():
+		t.Fatalf("got %+v after unsubscribing", event)
+	default:
+	}
+}
+
+func TestHubResetClosesStreams(t *testing.T) {
+	hub := NewHub()
+	hub.SetLive(true)
+	source := hub.NewSource()
+	source.Subscribe("C1", nil)
+
+	hub.Reset()
+	if hub.Live() {
+		t.Fatal("hub still live after a reset")
+	}
+	if _, ok := <-source.Events(); ok {
+		t.Fatal("stream still open after a reset")
+	}
+
+	// the session closing its source afterwards is fine
+	source.Close()
+	hub.Publish(Event{Kind: MessageNew, Channel: "C1", Message: message("1.000001", "late")})
+}
+
+// always hands back the same history, newest first like slack
+type fakeHistory struct {
+	messages []slack.Message
+}
+
+func (f fakeHistory) GetConversationHistory(params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	return &slack.GetConversationHistoryResponse{Messages: f.messages}, nil
+}
+
+func TestPollerDiffsHistory(t *testing.T) {
+	history := fakeHistory{[]slack.Message{message("1.000003", "new"), message("1.000002", "edited")}}
+	poller := NewPoller(history, time.Millisecond)
+	defer poller.Close()
+
+	poller.Subscribe("C1", []slack.Message{message("1.000002", "edit me"), message("1.000001", "delete me")})
+
+	want := map[Kind]string{MessageNew: "1.000003", MessageChanged: "1.000002", MessageDeleted: "1.000001"}
+	for len(want) > 0 {
+		event := receive(t, poller)
+		if timestamp, ok := want[event.Kind]; !ok || event.Message.Timestamp != timestamp || event.Channel != "C1" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+		delete(want, event.Kind)
+	}
+}
+
+func TestFromMessageEventSubtypes(t *testing.T) {
+	tests := []struct {
+		subType string
+		kind    Kind
+		ok      bool
+	}{
+		{"", MessageNew, true},
+		{"thread_broadcast", MessageNew, true},
+		{"me_message", MessageNew, true},
+		{"file_share", MessageNew, true},
+		{"bot_message", MessageNew, true},
+		{"message_replied", 0, false},
+		{"channel_topic", 0, false},
+		{"channel_join", 0, false},
+	}
+
+	for _, test := range tests {
+		event, ok := fromMessageEvent(&slackevents.MessageEvent{SubType: test.subType, Channel: "C1", TimeStamp: "1.000001"})
+		if ok != test.ok || ok && event.Kind != test.kind {
+			t.Errorf("subtype %q: got %v %v, want %v %v", test.subType, event.Kind, ok, test.kind, test.ok)
+		}
+	}
+}
@@ -71,5 +71,5 @@ func RedirectToSlackInstallHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	slackClientID := os.Getenv("SLACK_CLIENT_ID")
 	log.Info("redirecting to slack install page", "slackClientID", slackClientID)
-	http.Redirect(w, r, "https://slack.com/oauth/v2/authorize?scope=&user_scope=channels%3Aread%2Cchannels%3Awrite%2Cchannels%3Ahistory%2Cgroups%3Ahistory%2Cgroups%3Aread%2Cgroups%3Awrite%2Cmpim%3Ahistory%2Cmpim%3Aread%2Cmpim%3Awrite%2Cim%3Ahistory%2Cim%3Aread%2Cim%3Awrite%2Cidentify%2Cchat%3Awrite%2Cusers.profile%3Aread%2Cusers%3Aread%2Csearch%3Aread&redirect_uri="+url.QueryEscape(os.Getenv("REDIRECT_URL")+"/slack/install")+"&client_id="+slackClientID+"&state="+state, http.StatusFound)
+	http.Redirect(w, r, "https://slack.com/oauth/v2/authorize?scope=&user_scope=channels%3Aread%2Cchannels%3Awrite%2Cchannels%3Ahistory%2Cgroups%3Ahistory%2Cgroups%3Aread%2Cgroups%3Awrite%2Cmpim%3Ahistory%2Cmpim%3Aread%2Cmpim%3Awrite%2Cim%3Ahistory%2Cim%3Aread%2Cim%3Awrite%2Cidentify%2Cchat%3Awrite%2Cfiles%3Aread%2Cfiles%3Awrite%2Cpins%3Aread%2Cpins%3Awrite%2Cbookmarks%3Aread%2Creactions%3Aread%2Creactions%3Awrite%2Cusers.profile%3Aread%2Cusers.profile%3Awrite%2Cusers%3Aread%2Cusers%3Aread.email%2Cusers%3Awrite%2Cdnd%3Awrite%2Creminders%3Awrite%2Csearch%3Aread%2Cstars%3Aread%2Cstars%3Awrite&redirect_uri="+url.QueryEscape(os.Getenv("REDIRECT_URL")+"/slack/install")+"&client_id="+slackClientID+"&state="+state, http.StatusFound)
 }
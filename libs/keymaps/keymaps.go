@@ -3,10 +3,19 @@ package keymaps
 import "github.com/charmbracelet/bubbles/key"
 
 type KeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
 	Tab      key.Binding
 	ShiftTab key.Binding
 	Enter    key.Binding
 	Back     key.Binding
+	Thread   key.Binding
+	AlsoSend key.Binding
+	Preview  key.Binding
+	Browse   key.Binding
+	Info     key.Binding
+	Status   key.Binding
+	Switcher key.Binding
 	Help     key.Binding
 	Quit     key.Binding
 }
@@ -20,10 +29,18 @@ func (k KeyMap) ShortHelp() []key.Binding {
 // FullHelp returns keybindings for the expanded help view. It's part of the
 // key.Map interface.
 func (k KeyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{{k.Help}, {k.Quit}, {k.Enter}, {k.Back}, {k.Tab}, {k.ShiftTab}}
+	return [][]key.Binding{{k.Help}, {k.Quit}, {k.Enter}, {k.Back}, {k.Tab}, {k.ShiftTab}, {k.Up}, {k.Down}, {k.Thread}, {k.AlsoSend}, {k.Preview}, {k.Browse}, {k.Info}, {k.Status}, {k.Switcher}}
 }
 
 var Keys = KeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "select previous message"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "select next message"),
+	),
 	Help: key.NewBinding(
 		key.WithKeys("?"),
 		key.WithHelp("?", "toggle help"),
@@ -40,6 +57,34 @@ var Keys = KeyMap{
 		key.WithKeys("ctrl+b"),
 		key.WithHelp("ctrl+b", "go back"),
 	),
+	Thread: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "open thread"),
+	),
+	AlsoSend: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "also send to channel"),
+	),
+	Preview: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "toggle message preview"),
+	),
+	Browse: key.NewBinding(
+		key.WithKeys("ctrl+n"),
+		key.WithHelp("ctrl+n", "browse channels or start a dm"),
+	),
+	Info: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "toggle channel info"),
+	),
+	Status: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "set your status"),
+	),
+	Switcher: key.NewBinding(
+		key.WithKeys("ctrl+k"),
+		key.WithHelp("ctrl+k", "jump to a conversation"),
+	),
 	Tab: key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("tab", "switch tab"),
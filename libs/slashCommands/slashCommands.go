@@ -0,0 +1,367 @@
+package slashCommands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/utils"
+)
+
+// everything a command might need to know about where it was typed
+type Context struct {
+	Client  *slack.Client
+	UserID  string
+	Channel string
+	// the thread being replied to, empty in the channel itself
+	Thread string
+	// the channels the user is in, for resolving #names
+	Channels []slack.Channel
+	// everyone in the user's workspace, for resolving @names
+	Users []slack.User
+}
+
+type Result struct {
+	// shown above the composer once the command finishes
+	Status string
+	// the user joined or left something so the channel lists need reloading
+	ChannelsChanged bool
+	// the conversation the command was typed in should close, like after /leave
+	CloseConversation bool
+}
+
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(ctx Context, args string) (Result, error)
+}
+
+var registry = map[string]Command{}
+
+// adds a command, registering a name twice replaces the earlier one
+func Register(command Command) {
+	registry[command.Name] = command
+}
+
+func Lookup(name string) (Command, bool) {
+	command, ok := registry[name]
+	return command, ok
+}
+
+// every registered command sorted by name
+func Commands() []Command {
+	commands := make([]Command, 0, len(registry))
+	for _, command := range registry {
+		commands = append(commands, command)
+	}
+	slices.SortFunc(commands, func(a, b Command) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return commands
+}
+
+// whether text is a command, a doubled slash is how you send a message that starts with one
+func IsCommand(text string) bool {
+	return strings.HasPrefix(text, "/") && !strings.HasPrefix(text, "//")
+}
+
+// splits "/topic release day" into "topic" and "release day"
+func Parse(text string) (string, string) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(text), "/"), " ")
+	return strings.ToLower(name), strings.TrimSpace(args)
+}
+
+func usageError(command string) error {
+	return fmt.Errorf("usage: %s", registry[command].Usage)
+}
+
+func init() {
+	Register(Command{Name: "help", Usage: "/help", Description: "list every command", Run: help})
+	Register(Command{Name: "join", Usage: "/join #channel", Description: "join a public channel", Run: join})
+	Register(Command{Name: "leave", Usage: "/leave [#channel]", Description: "leave this or another channel", Run: leave})
+	Register(Command{Name: "topic", Usage: "/topic text", Description: "set the channel topic", Run: topic})
+	Register(Command{Name: "purpose", Usage: "/purpose text", Description: "set the channel purpose", Run: purpose})
+	Register(Command{Name: "invite", Usage: "/invite @someone [@someone else]", Description: "add people to this channel", Run: invite})
+	Register(Command{Name: "status", Usage: "/status [:emoji:] text | clear", Description: "set or clear your status", Run: status})
+	Register(Command{Name: "away", Usage: "/away", Description: "toggle between away and active", Run: away})
+	Register(Command{Name: "dnd", Usage: "/dnd 30m | off", Description: "pause notifications for a while", Run: dnd})
+	Register(Command{Name: "me", Usage: "/me text", Description: "post an action message", Run: me})
+	Register(Command{Name: "remind", Usage: "/remind me|#channel|@someone \"what\" when", Description: "set a slack reminder", Run: remind})
+}
+
+// kept to one line since it shows above the composer, typing / lists what each one does
+func help(_ Context, _ string) (Result, error) {
+	names := []string{}
+	for _, command := range Commands() {
+		names = append(names, "/"+command.Name)
+	}
+	return Result{Status: "commands: " + strings.Join(names, " ") + " (type / to see what they do)"}, nil
+}
+
+var (
+	channelTokenRe = regexp.MustCompile(`^<#(\w+)(\|[^>]*)?>$`)
+	userTokenRe    = regexp.MustCompile(`<@(U\w+)(\|[^>]*)?>`)
+)
+
+// finds a channel from #name, a <#C123|name> token or a raw id, falling back to every
+// public channel in the workspace since you can't be in one you're about to join
+func resolveChannel(ctx Context, arg string) (string, error) {
+	if submatch := channelTokenRe.FindStringSubmatch(arg); submatch != nil {
+		return submatch[1], nil
+	}
+
+	name := strings.TrimPrefix(arg, "#")
+	for _, channel := range ctx.Channels {
+		if channel.Name == name || channel.ID == name {
+			return channel.ID, nil
+		}
+	}
+
+	cursor := ""
+	for {
+		channels, next, err := ctx.Client.GetConversations(&slack.GetConversationsParameters{Cursor: cursor, ExcludeArchived: true, Limit: 1000, Types: []string{"public_channel"}})
+		if err != nil {
+			return "", err
+		}
+		for _, channel := range channels {
+			if channel.Name == name || channel.ID == name {
+				return channel.ID, nil
+			}
+		}
+		if next == "" {
+			return "", fmt.Errorf("couldn't find a channel called #%s", name)
+		}
+		cursor = next
+	}
+}
+
+// pulls user ids out of args, @names are resolved the same way outgoing messages are
+func resolveUsers(ctx Context, args string) ([]string, error) {
+	encoded, warnings := utils.EncodeMentions(args, ctx.Users, ctx.Channels)
+	if len(warnings) > 0 {
+		return nil, errors.New(strings.Join(warnings, ", "))
+	}
+
+	users := []string{}
+	for _, submatch := range userTokenRe.FindAllStringSubmatch(encoded, -1) {
+		users = append(users, submatch[1])
+	}
+
+	// anything still starting with @ wasn't anyone we know
+	for _, word := range strings.Fields(userTokenRe.ReplaceAllString(encoded, "")) {
+		if strings.HasPrefix(word, "@") {
+			return nil, fmt.Errorf("couldn't find anyone called %s", word)
+		}
+	}
+
+	return users, nil
+}
+
+func join(ctx Context, args string) (Result, error) {
+	if args == "" {
+		return Result{}, usageError("join")
+	}
+
+	channel, err := resolveChannel(ctx, args)
+	if err != nil {
+		return Result{}, err
+	}
+
+	joined, _, _, err := ctx.Client.JoinConversation(channel)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Status: "joined #" + joined.Name, ChannelsChanged: true}, nil
+}
+
+func leave(ctx Context, args string) (Result, error) {
+	channel := ctx.Channel
+	if args != "" {
+		var err error
+		if channel, err = resolveChannel(ctx, args); err != nil {
+			return Result{}, err
+		}
+	}
+
+	if _, err := ctx.Client.LeaveConversation(channel); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Status: "left the channel", ChannelsChanged: true, CloseConversation: channel == ctx.Channel}, nil
+}
+
+func topic(ctx Context, args string) (Result, error) {
+	if args == "" {
+		return Result{}, usageError("topic")
+	}
+
+	if _, err := ctx.Client.SetTopicOfConversation(ctx.Channel, args); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Status: "set the topic to " + args}, nil
+}
+
+func purpose(ctx Context, args string) (Result, error) {
+	if args == "" {
+		return Result{}, usageError("purpose")
+	}
+
+	if _, err := ctx.Client.SetPurposeOfConversation(ctx.Channel, args); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Status: "set the purpose to " + args}, nil
+}
+
+func invite(ctx Context, args string) (Result, error) {
+	users, err := resolveUsers(ctx, args)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(users) == 0 {
+		return Result{}, usageError("invite")
+	}
+
+	if _, err := ctx.Client.InviteUsersToConversation(ctx.Channel, users...); err != nil {
+		return Result{}, err
+	}
+
+	if len(users) == 1 {
+		return Result{Status: "invited 1 person"}, nil
+	}
+	return Result{Status: fmt.Sprintf("invited %d people", len(users))}, nil
+}
+
+var leadingEmojiRe = regexp.MustCompile(`^(:[\w+\-]+:)\s*`)
+
+func status(ctx Context, args string) (Result, error) {
+	switch strings.ToLower(args) {
+	case "":
+		return Result{}, usageError("status")
+	case "clear":
+		if err := ctx.Client.SetUserCustomStatus("", "", 0); err != nil {
+			return Result{}, err
+		}
+		return Result{Status: "cleared your status"}, nil
+	}
+
+	emoji := ""
+	if submatch := leadingEmojiRe.FindStringSubmatch(args); submatch != nil {
+		emoji = submatch[1]
+		args = strings.TrimPrefix(args, submatch[0])
+	}
+
+	if err := ctx.Client.SetUserCustomStatus(args, emoji, 0); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Status: strings.TrimSpace("set your status to " + emoji + " " + args)}, nil
+}
+
+func away(ctx Context, _ string) (Result, error) {
+	presence, err := ctx.Client.GetUserPresence(ctx.UserID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// slack only lets you force away or go back to it working presence out itself
+	if presence.Presence == "away" {
+		if err := ctx.Client.SetUserPresence("auto"); err != nil {
+			return Result{}, err
+		}
+		return Result{Status: "you're active again"}, nil
+	}
+
+	if err := ctx.Client.SetUserPresence("away"); err != nil {
+		return Result{}, err
+	}
+	return Result{Status: "you're now away"}, nil
+}
+
+func dnd(ctx Context, args string) (Result, error) {
+	switch strings.ToLower(args) {
+	case "":
+		return Result{}, usageError("dnd")
+	case "off":
+		if _, err := ctx.Client.EndSnooze(); err != nil {
+			return Result{}, err
+		}
+		return Result{Status: "notifications are back on"}, nil
+	}
+
+	// a bare number is minutes, like slack's own /dnd
+	duration, err := time.ParseDuration(args)
+	if minutes, convErr := strconv.Atoi(args); convErr == nil {
+		duration, err = time.Duration(minutes)*time.Minute, nil
+	}
+	if err != nil || duration < time.Minute {
+		return Result{}, usageError("dnd")
+	}
+
+	if _, err := ctx.Client.SetSnooze(int(duration.Minutes())); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Status: "notifications paused until " + time.Now().Add(duration).Format(time.Kitchen)}, nil
+}
+
+func me(ctx Context, args string) (Result, error) {
+	if args == "" {
+		return Result{}, usageError("me")
+	}
+
+	text, _ := utils.EncodeMentions(args, ctx.Users, ctx.Channels)
+	options := []slack.MsgOption{slack.MsgOptionText(text, false), slack.MsgOptionMeMessage()}
+	if ctx.Thread != "" {
+		options = append(options, slack.MsgOptionTS(ctx.Thread))
+	}
+
+	if _, _, err := ctx.Client.PostMessage(ctx.Channel, options...); err != nil {
+		return Result{}, err
+	}
+
+	return Result{}, nil
+}
+
+var remindRe = regexp.MustCompile(`^(\S+)\s+"([^"]+)"\s+(.+)$`)
+
+func remind(ctx Context, args string) (Result, error) {
+	submatch := remindRe.FindStringSubmatch(args)
+	if submatch == nil {
+		return Result{}, usageError("remind")
+	}
+	target, what, when := submatch[1], submatch[2], submatch[3]
+
+	var err error
+	switch {
+	case target == "me":
+		_, err = ctx.Client.AddUserReminder(ctx.UserID, what, when)
+	case strings.HasPrefix(target, "#") || strings.HasPrefix(target, "<#"):
+		var channel string
+		if channel, err = resolveChannel(ctx, target); err == nil {
+			_, err = ctx.Client.AddChannelReminder(channel, what, when)
+		}
+	default:
+		var users []string
+		if users, err = resolveUsers(ctx, target); err == nil {
+			if len(users) != 1 {
+				return Result{}, usageError("remind")
+			}
+			_, err = ctx.Client.AddUserReminder(users[0], what, when)
+		}
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Status: "reminder set for " + when}, nil
+}
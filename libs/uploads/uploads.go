@@ -0,0 +1,299 @@
+package uploads
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/scp"
+	"github.com/pkg/sftp"
+	"github.com/slack-go/slack"
+
+	"charming-slack/libs/database"
+)
+
+var errNoTarget = errors.New("upload into a conversation, like #general, @someone or a channel id")
+
+// lets `scp build.log host:#channel-name` post the file to slack
+func ScpMiddleware() wish.Middleware {
+	return scp.Middleware(nil, scpHandler{})
+}
+
+// the same thing over sftp, every conversation shows up as a folder in the root
+func SftpSubsystem() ssh.SubsystemHandler {
+	return func(s ssh.Session) {
+		slackClient, err := clientForSession(s)
+		if err != nil {
+			wish.Fatalln(s, err)
+			return
+		}
+
+		handler := sftpHandler{slackClient}
+		server := sftp.NewRequestServer(s, sftp.Handlers{
+			FileGet:  handler,
+			FilePut:  handler,
+			FileCmd:  handler,
+			FileList: handler,
+		})
+		if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("sftp session ended", "error", err)
+		}
+		server.Close()
+	}
+}
+
+// uses the same public key check as the tui so only people who signed up can upload
+func clientForSession(s ssh.Session) (*slack.Client, error) {
+	userData, ok := database.AuthenticateUser(s.User(), s.PublicKey())
+	if !ok {
+		return nil, fmt.Errorf("unknown user or key, ssh in without a command to sign up first")
+	}
+	if userData.SlackToken == "" {
+		return nil, fmt.Errorf("slack isn't connected yet, ssh in without a command to finish signing up")
+	}
+
+	return slack.New(userData.SlackToken), nil
+}
+
+// a path looks like #channel-name/file.txt, @someone/file.txt or C0123/file.txt
+// and the conversation can carry an initial comment as #channel-name:the comment
+func parsePath(p string) (target string, comment string, filename string) {
+	segments := strings.Split(strings.Trim(path.Clean("/"+p), "/"), "/")
+	if len(segments) < 2 {
+		return "", "", ""
+	}
+
+	target, comment, _ = strings.Cut(segments[0], ":")
+	return target, comment, segments[len(segments)-1]
+}
+
+func resolveConversation(slackClient *slack.Client, target string) (string, error) {
+	switch {
+	case strings.HasPrefix(target, "#"):
+		name := strings.TrimPrefix(target, "#")
+		for _, channel := range listConversations(slackClient) {
+			if channel.Name == name {
+				return channel.ID, nil
+			}
+		}
+		return "", fmt.Errorf("couldn't find a channel called %s that you're in", target)
+	case strings.HasPrefix(target, "@"):
+		userID, err := resolveUser(slackClient, strings.TrimPrefix(target, "@"))
+		if err != nil {
+			return "", err
+		}
+		channel, _, _, err := slackClient.OpenConversation(&slack.OpenConversationParameters{Users: []string{userID}, ReturnIM: true})
+		if err != nil {
+			return "", fmt.Errorf("couldn't open a dm with %s: %w", target, err)
+		}
+		return channel.ID, nil
+	case target != "":
+		return target, nil
+	}
+
+	return "", errNoTarget
+}
+
+func listConversations(slackClient *slack.Client) []slack.Channel {
+	channels, _, err := slackClient.GetConversationsForUser(&slack.GetConversationsForUserParameters{Limit: 10000, ExcludeArchived: true, Types: []string{"public_channel", "private_channel"}})
+	if err != nil {
+		log.Error("error listing conversations for upload", "err", err)
+	}
+
+	return channels
+}
+
+// only asks the uploader's own workspace, anyone else's cached users could share the name
+func resolveUser(slackClient *slack.Client, name string) (string, error) {
+	users, err := slackClient.GetUsers()
+	if err != nil {
+		return "", fmt.Errorf("couldn't look up @%s: %w", name, err)
+	}
+	for _, user := range users {
+		if user.ID == name || strings.EqualFold(user.Name, name) || strings.EqualFold(user.Profile.DisplayName, name) || strings.EqualFold(user.RealName, name) {
+			return user.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("couldn't find anyone called @%s", name)
+}
+
+func upload(slackClient *slack.Client, p string, size int64, reader io.Reader) error {
+	target, comment, filename := parsePath(p)
+	channel, err := resolveConversation(slackClient, target)
+	if err != nil {
+		return err
+	}
+
+	_, err = slackClient.UploadFileV2(slack.UploadFileV2Parameters{
+		Reader:         reader,
+		FileSize:       int(size),
+		Filename:       filename,
+		Title:          filename,
+		InitialComment: comment,
+		Channel:        channel,
+	})
+	if err != nil {
+		return fmt.Errorf("slack didn't take the upload: %w", err)
+	}
+
+	log.Info("uploaded file", "file", filename, "channel", channel)
+	return nil
+}
+
+type scpHandler struct{}
+
+// there's nothing to make, the first folder is the conversation
+func (scpHandler) Mkdir(ssh.Session, *scp.DirEntry) error {
+	return nil
+}
+
+func (scpHandler) Write(s ssh.Session, entry *scp.FileEntry) (int64, error) {
+	slackClient, err := clientForSession(s)
+	if err != nil {
+		return 0, err
+	}
+
+	counter := &countingReader{reader: entry.Reader}
+	if err := upload(slackClient, entry.Filepath, entry.Size, counter); err != nil {
+		return counter.read, err
+	}
+
+	// drain whatever slack didn't read so scp stays in sync
+	_, _ = io.Copy(io.Discard, counter)
+	return counter.read, nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+type sftpHandler struct {
+	slackClient *slack.Client
+}
+
+func (sftpHandler) Fileread(*sftp.Request) (io.ReaderAt, error) {
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// sftp writes at offsets so the file is spooled to disk and uploaded on close
+func (h sftpHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	if target, _, _ := parsePath(r.Filepath); target == "" {
+		return nil, errNoTarget
+	}
+
+	file, err := os.CreateTemp("", "charming-slack-upload-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &spooledUpload{file: file, path: r.Filepath, slackClient: h.slackClient}, nil
+}
+
+func (sftpHandler) Filecmd(r *sftp.Request) error {
+	// clients like to set times and permissions after a put, there's nothing to set
+	if r.Method == "Setstat" {
+		return nil
+	}
+
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+func (h sftpHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	p := path.Clean("/" + r.Filepath)
+
+	switch r.Method {
+	case "List":
+		if p != "/" {
+			return listerAt{}, nil
+		}
+		infos := []os.FileInfo{}
+		for _, channel := range listConversations(h.slackClient) {
+			infos = append(infos, fileInfo{name: "#" + channel.Name, dir: true})
+		}
+		return listerAt(infos), nil
+	case "Stat":
+		// the root and any conversation look like folders, nothing inside them exists
+		if p == "/" || strings.Count(p, "/") == 1 {
+			return listerAt{fileInfo{name: path.Base(p), dir: true}}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+type spooledUpload struct {
+	file        *os.File
+	path        string
+	slackClient *slack.Client
+}
+
+func (u *spooledUpload) WriteAt(p []byte, off int64) (int, error) {
+	return u.file.WriteAt(p, off)
+}
+
+func (u *spooledUpload) Close() error {
+	defer os.Remove(u.file.Name())
+	defer u.file.Close()
+
+	info, err := u.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := u.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	err = upload(u.slackClient, u.path, info.Size(), u.file)
+	if err != nil {
+		log.Error("error uploading file", "err", err)
+	}
+	return err
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(infos []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+
+	n := copy(infos, l[offset:])
+	if n < len(infos) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+type fileInfo struct {
+	name string
+	dir  bool
+}
+
+func (f fileInfo) Name() string       { return f.name }
+func (f fileInfo) Size() int64        { return 0 }
+func (f fileInfo) ModTime() time.Time { return time.Now() }
+func (f fileInfo) IsDir() bool        { return f.dir }
+func (f fileInfo) Sys() any           { return nil }
+func (f fileInfo) Mode() fs.FileMode {
+	if f.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
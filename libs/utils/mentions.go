@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+var (
+	// code is sent as written so mentions inside it are left alone
+	codeRe = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	// an @name or #name at the start of a word, anything already inside <> is skipped
+	outgoingMentionRe = regexp.MustCompile(`(^|[\s(*_~])([@#])([\p{L}\p{N}_][\p{L}\p{N}_.\-]*)`)
+)
+
+// the everyone style mentions slack writes as <!name>
+var specialMentions = []string{"here", "channel", "everyone"}
+
+// the reverse of UserIdParser, turns the @names and #channels typed in a message into
+// the tokens slack needs to notify people and link channels. names that could mean more
+// than one person are left as plain text and come back as warnings. users should only be
+// the sender's own workspace so nobody else's people can be matched or named in a warning
+func EncodeMentions(s string, users []slack.User, channels []slack.Channel) (string, []string) {
+	warnings := []string{}
+
+	encode := func(text string) string {
+		return outgoingMentionRe.ReplaceAllStringFunc(text, func(match string) string {
+			submatch := outgoingMentionRe.FindStringSubmatch(match)
+			before, sigil, name := submatch[1], submatch[2], submatch[3]
+
+			// a full stop or dash after a name is punctuation, not part of it
+			trimmed := strings.TrimRight(name, ".-")
+			after := name[len(trimmed):]
+			name = trimmed
+
+			var token string
+			var warning string
+			if sigil == "@" {
+				token, warning = encodeUser(name, users)
+			} else {
+				token, warning = encodeChannel(name, channels)
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			if token == "" {
+				return match
+			}
+
+			return before + token + after
+		})
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, code := range codeRe.FindAllStringIndex(s, -1) {
+		b.WriteString(encode(s[last:code[0]]))
+		b.WriteString(s[code[0]:code[1]])
+		last = code[1]
+	}
+	b.WriteString(encode(s[last:]))
+
+	return b.String(), warnings
+}
+
+func encodeUser(name string, users []slack.User) (string, string) {
+	for _, special := range specialMentions {
+		if strings.EqualFold(name, special) {
+			return "<!" + special + ">", ""
+		}
+	}
+
+	// display names win, real names are only a fallback for people who never set one
+	matches := []slack.User{}
+	for _, user := range users {
+		if strings.EqualFold(user.Profile.DisplayName, name) {
+			matches = append(matches, user)
+		}
+	}
+	if len(matches) == 0 {
+		for _, user := range users {
+			if strings.EqualFold(user.RealName, name) {
+				matches = append(matches, user)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", ""
+	case 1:
+		return "<@" + matches[0].ID + ">", ""
+	}
+
+	names := []string{}
+	for _, user := range matches {
+		names = append(names, user.RealName)
+	}
+	slices.Sort(names)
+	return "", "@" + name + " could be " + strings.Join(names, " or ")
+}
+
+func encodeChannel(name string, channels []slack.Channel) (string, string) {
+	matches := []slack.Channel{}
+	for _, channel := range channels {
+		if strings.EqualFold(channel.Name, name) {
+			matches = append(matches, channel)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", ""
+	case 1:
+		return "<#" + matches[0].ID + "|" + matches[0].Name + ">", ""
+	}
+
+	return "", "#" + name + " matches more than one channel"
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func user(id string, displayName string, realName string) slack.User {
+	return slack.User{ID: id, RealName: realName, Profile: slack.UserProfile{DisplayName: displayName, RealName: realName}}
+}
+
+func channel(id string, name string) slack.Channel {
+	c := slack.Channel{}
+	c.ID = id
+	c.Name = name
+	return c
+}
+
+func TestEncodeMentions(t *testing.T) {
+	users := []slack.User{
+		user("U1", "jane", "Jane Doe"),
+		user("U2", "", "bob"),
+		user("U3", "bob", "Robert Smith"),
+		user("U4", "mary.o-brien", "Mary O'Brien"),
+		user("U5", "sam", "Sam One"),
+		user("U6", "sam", "Sam Two"),
+		user("U8", "", "Carol"),
+	}
+	channels := []slack.Channel{channel("C1", "general"), channel("C2", "dev-ops")}
+
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		warnings []string
+	}{
+		{"display name", "hi @jane", "hi <@U1>", nil},
+		{"case insensitive", "hi @JANE", "hi <@U1>", nil},
+		{"display name wins over real name", "ask @bob", "ask <@U3>", nil},
+		{"real name for someone with no display name", "ask @carol", "ask <@U8>", nil},
+		{"dots and dashes inside a name", "cc @mary.o-brien", "cc <@U4>", nil},
+		{"trailing punctuation stays outside", "thanks @jane.", "thanks <@U1>.", nil},
+		{"names stop at a space", "hi @Mary O'Brien", "hi @Mary O'Brien", nil},
+		{"email addresses are left alone", "mail jane@example.com", "mail jane@example.com", nil},
+		{"unknown names are left alone", "hi @nobody", "hi @nobody", nil},
+		{"ambiguous names warn", "hi @sam", "hi @sam", []string{"@sam could be Sam One or Sam Two"}},
+		{"special mentions", "@here and @Channel", "<!here> and <!channel>", nil},
+		{"channels", "see #general and #dev-ops", "see <#C1|general> and <#C2|dev-ops>", nil},
+		{"unknown channels are left alone", "see #random", "see #random", nil},
+		{"inside formatting", "*@jane* (#general)", "*<@U1>* (<#C1|general>)", nil},
+		{"code is sent as written", "`@jane` and ```#general```", "`@jane` and ```#general```", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, warnings := EncodeMentions(test.text, users, channels)
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if !slices.Equal(warnings, test.warnings) {
+				t.Errorf("got warnings %q, want %q", warnings, test.warnings)
+			}
+		})
+	}
+}
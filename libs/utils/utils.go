@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"charming-slack/libs/database"
 	"image"
+	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"net/http"
 	"regexp"
+	"strings"
 
 	"github.com/KononK/resize"
 	"github.com/charmbracelet/lipgloss"
@@ -35,17 +38,41 @@ var (
 )
 
 func UserIdParser(s string, highlightedStyle lipgloss.Style, highlightedStyleBot lipgloss.Style, slackClient slack.Client) string {
+	return parseIds(s, highlightedStyle, highlightedStyleBot, func(userID string) database.SlackUserMap {
+		return database.GetUserOrCreate(userID, slackClient)
+	})
+}
+
+// the same as UserIdParser but never asks slack, for views drawing things whose users were looked up when they loaded
+func CachedUserIdParser(s string, highlightedStyle lipgloss.Style, highlightedStyleBot lipgloss.Style) string {
+	return parseIds(s, highlightedStyle, highlightedStyleBot, database.QuerySlackUserID)
+}
+
+// looks up the sender and everyone mentioned so CachedUserIdParser can draw them later
+func CacheUsers(slackClient slack.Client, userID string, text string) {
+	if userID != "" {
+		database.GetUserOrCreate(userID, slackClient)
+	}
+	for _, match := range userIdRe.FindAllStringSubmatch(text, -1) {
+		database.GetUserOrCreate(match[1], slackClient)
+	}
+}
+
+var userIdRe = regexp.MustCompile(`<@(U\w+)>`)
+
+func parseIds(s string, highlightedStyle lipgloss.Style, highlightedStyleBot lipgloss.Style, lookup func(string) database.SlackUserMap) string {
 	// look for things like <@U05JX2BHANT> and replace them with the proper display name
-	re := regexp.MustCompile(`<@(U\w+)>`)
-	result := re.ReplaceAllStringFunc(s, func(match string) string {
+	result := userIdRe.ReplaceAllStringFunc(s, func(match string) string {
 		// extract the user ID from the match
-		userID := re.FindStringSubmatch(match)[1]
+		userID := userIdRe.FindStringSubmatch(match)[1]
 		// get the display name for the user ID from the database
 		// return the display name as the replacement
 
-		user := database.GetUserOrCreate(userID, slackClient)
+		user := lookup(userID)
 		log.Info("names", "display name", user.DisplayName, "real name", user.RealName)
-		if user.DisplayName == "" {
+		if user.DisplayName == "" && user.RealName == "" {
+			return highlightedStyleBot.Render("@" + userID)
+		} else if user.DisplayName == "" {
 			return highlightedStyleBot.Render("@" + user.RealName + " (bot)")
 		} else {
 			return highlightedStyle.Render("@" + user.DisplayName)
@@ -62,7 +89,13 @@ func UserIdParser(s string, highlightedStyle lipgloss.Style, highlightedStyleBot
 		return channelStyle.Render("#" + channel)
 	})
 
-	return result2
+	// @here, @channel and @everyone
+	specialRe := regexp.MustCompile(`<!(here|channel|everyone)(\|[^>]*)?>`)
+	result3 := specialRe.ReplaceAllStringFunc(result2, func(match string) string {
+		return highlightedStyle.Render("@" + specialRe.FindStringSubmatch(match)[1])
+	})
+
+	return result3
 }
 
 func UrlParser(s string) string {
@@ -87,8 +120,18 @@ func EmojiParser(s string) string {
 		// get the emoji image url from slack
 		emojiUrl := database.QueryEmoji(emojiName)
 
+		// custom emoji can be aliases of other custom or built in emoji
+		if alias, ok := strings.CutPrefix(emojiUrl, "alias:"); ok {
+			emojiName = alias
+			emojiUrl = database.QueryEmoji(alias)
+		}
+
 		// check if the url is zero and if it isn't than sixel encode
 		if emojiUrl == "" {
+			// fall back to the built in emoji before giving up and leaving the name
+			if unicode, ok := StandardEmoji[emojiName]; ok {
+				return unicode
+			}
 			return ":" + emojiName + ":"
 		}
 
@@ -107,8 +150,13 @@ func SixelEncode(url string, width uint) string {
 	}
 	defer resp.Body.Close()
 
+	return SixelEncodeReader(resp.Body, width)
+}
+
+// same as SixelEncode for an image we already have, like a file that needed a token to download
+func SixelEncodeReader(r io.Reader, width uint) string {
 	// decode the image
-	img, _, err := image.Decode(resp.Body)
+	img, _, err := image.Decode(r)
 	if err != nil {
 		log.Error("erroring decoding image", "err", err)
 		return ""
@@ -24,7 +24,9 @@ import (
 
 	"charming-slack/libs/bubbleViews"
 	"charming-slack/libs/database"
+	"charming-slack/libs/events"
 	"charming-slack/libs/httpHandlers"
+	"charming-slack/libs/uploads"
 	"charming-slack/libs/utils"
 )
 
@@ -44,6 +46,7 @@ func main() {
 	// load the database
 	log.Info("Loading database")
 	database.LoadUserData()
+	database.LoadIndex()
 
 	http.HandleFunc("/slack/install", func(w http.ResponseWriter, r *http.Request) {
 		httpHandlers.SlackInstallHandler(w, r, database.SetUserData)
@@ -61,13 +64,26 @@ func main() {
 		}),
 		wish.WithMiddleware(
 			bubbleViews.FirstLineDefenseMiddleware(),
+			uploads.ScpMiddleware(),
 			logging.Middleware(),
 		),
+		wish.WithSubsystem("sftp", uploads.SftpSubsystem()),
 	)
 	if err != nil {
 		log.Error("Could not start server", "error", err)
 	}
 
+	// socket mode is optional, without an app token every session polls for new messages instead
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	if appToken := os.Getenv("SLACK_APP_TOKEN"); appToken != "" {
+		log.Info("Starting socket mode event stream")
+		go func() {
+			if err := events.RunSocketMode(eventsCtx, events.DefaultHub, appToken); err != nil && !errors.Is(err, context.Canceled) {
+				log.Error("Socket mode stopped", "error", err)
+			}
+		}()
+	}
+
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	log.Info("Starting SSH server", "host", host, "port", os.Getenv("SSH_PORT"))
@@ -87,6 +103,7 @@ func main() {
 	}()
 
 	<-done
+	stopEvents()
 	log.Info("Stopping SSH server")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer func() { cancel() }()
@@ -97,4 +114,5 @@ func main() {
 	// save the database
 	log.Info("Saving database")
 	database.SaveUserData()
+	database.SaveIndex()
 }